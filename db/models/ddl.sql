@@ -24,6 +24,22 @@ CREATE TABLE IF NOT EXISTS geocodex (
 
 ALTER TABLE geocodex OWNER TO thorcast;
 
+-- weather.gov grid point of each location, from api.weather.gov/points
+ALTER TABLE geocodex
+    ADD COLUMN IF NOT EXISTS cwa VARCHAR(3),
+    ADD COLUMN IF NOT EXISTS grid_x INTEGER,
+    ADD COLUMN IF NOT EXISTS grid_y INTEGER,
+    ADD COLUMN IF NOT EXISTS forecast_url VARCHAR,
+    ADD COLUMN IF NOT EXISTS forecast_hourly_url VARCHAR,
+    ADD COLUMN IF NOT EXISTS forecast_grid_data_url VARCHAR,
+    ADD COLUMN IF NOT EXISTS observation_stations_url VARCHAR,
+    ADD COLUMN IF NOT EXISTS time_zone VARCHAR,
+    ADD COLUMN IF NOT EXISTS forecast_zone VARCHAR,
+    ADD COLUMN IF NOT EXISTS county VARCHAR,
+    ADD COLUMN IF NOT EXISTS radar_station VARCHAR,
+    ADD COLUMN IF NOT EXISTS points_updated_at TIMESTAMP
+;
+
 BEGIN;
 DROP TRIGGER IF EXISTS geocodex_update_timestamp ON geocodex;
 
@@ -33,6 +49,26 @@ FOR EACH ROW
 EXECUTE PROCEDURE trigger_update_timestamp();
 COMMIT;
 
+CREATE TABLE IF NOT EXISTS preferences (
+    user_id VARCHAR NOT NULL,
+    units VARCHAR(2) NOT NULL CHECK (units IN ('us', 'si')),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id)
+)
+;
+
+ALTER TABLE preferences OWNER TO thorcast;
+
+BEGIN;
+DROP TRIGGER IF EXISTS preferences_update_timestamp ON preferences;
+
+CREATE TRIGGER preferences_update_timestamp
+BEFORE UPDATE ON preferences
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_update_timestamp();
+COMMIT;
+
 CREATE TABLE IF NOT EXISTS states (
     id uuid NOT NULL DEFAULT uuid_generate_v4(),
     name varchar(20),
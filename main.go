@@ -1,7 +1,9 @@
 package main
 
+import "github.com/kylep342/thorcast-server/pkg/app"
+
 func main() {
-	a := App{}
+	a := app.App{}
 	a.Initialize()
 	a.Run()
 }
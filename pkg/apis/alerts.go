@@ -0,0 +1,66 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/models"
+)
+
+// Alert is a weather alert (a watch, warning, advisory, etc.) issued
+// by the National Weather Service
+type Alert struct {
+	ID            string     `json:"id"`
+	AreaDesc      string     `json:"areaDesc"`
+	AffectedZones []string   `json:"affectedZones"`
+	Sent          time.Time  `json:"sent"`
+	Effective     time.Time  `json:"effective"`
+	Onset         *time.Time `json:"onset"`
+	Expires       *time.Time `json:"expires"`
+	Ends          *time.Time `json:"ends"`
+	Status        string     `json:"status"`
+	MessageType   string     `json:"messageType"`
+	Category      string     `json:"category"`
+	Severity      string     `json:"severity"`
+	Certainty     string     `json:"certainty"`
+	Urgency       string     `json:"urgency"`
+	Event         string     `json:"event"`
+	SenderName    string     `json:"senderName"`
+	Headline      string     `json:"headline"`
+	Description   string     `json:"description"`
+	Instruction   string     `json:"instruction"`
+	Response      string     `json:"response"`
+}
+
+// AlertFeature is a GeoJSON feature holding an Alert
+type AlertFeature struct {
+	ID         string `json:"id"`
+	Properties Alert  `json:"properties"`
+}
+
+// Alerts holds data from the request to api.weather.gov/alerts/active
+type Alerts struct {
+	Title    string         `json:"title"`
+	Updated  string         `json:"updated"`
+	Features []AlertFeature `json:"features"`
+}
+
+// AlertProvider is a source of weather alerts
+// FetchAlerts returns the alerts in effect at the given coordinates
+type AlertProvider interface {
+	FetchAlerts(ctx context.Context, c models.Coordinates) (Alerts, error)
+}
+
+// FetchAlerts queries api.weather.gov/alerts/active for the alerts in
+// effect at the given coordinates
+func (wg *WeatherGov) FetchAlerts(ctx context.Context, c models.Coordinates) (Alerts, error) {
+	requestURL := fmt.Sprintf("%s?point=%.4f,%.4f", wg.AlertsURL, c.Lat, c.Lng)
+	var a Alerts
+	if err := wg.Client.GetJSON(ctx, requestURL, &a); err != nil {
+		log.Printf("Error fetching alerts\nError is %s\n", err.Error())
+		return Alerts{}, err
+	}
+	return a, nil
+}
@@ -0,0 +1,145 @@
+package apis
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Defaults of a Breaker
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is the cause of requests rejected by an open Breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a Breaker
+type BreakerState int
+
+// A closed Breaker lets every request through
+// An open Breaker rejects every request until its cooldown has passed
+// A half-open Breaker lets a single probe through, closing again if it
+// succeeds and reopening if it fails
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerOptions configures a Breaker
+// Threshold is the number of consecutive failures which open the Breaker
+// Cooldown is how long it stays open before letting a probe through
+type BreakerOptions struct {
+	Threshold int
+	Cooldown  time.Duration
+}
+
+// BreakerStatus is a snapshot of a Breaker, as reported on /api/status
+type BreakerStatus struct {
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+}
+
+// Breaker is a circuit breaker guarding an upstream host
+// It is safe for concurrent use
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probeAt  time.Time
+}
+
+// NewBreaker creates a closed Breaker, filling in defaults for unset options
+func NewBreaker(opts BreakerOptions) *Breaker {
+	if opts.Threshold <= 0 {
+		opts.Threshold = defaultBreakerThreshold
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = defaultBreakerCooldown
+	}
+	return &Breaker{
+		threshold: opts.Threshold,
+		cooldown:  opts.Cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a request may be made
+// Once the cooldown of an open Breaker has passed it lets one probe
+// through; should the probe never report back, another is let through
+// after a further cooldown
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probeAt = now
+		return true
+	case BreakerHalfOpen:
+		if now.Sub(b.probeAt) < b.cooldown {
+			return false
+		}
+		b.probeAt = now
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a request which reached the upstream host, closing
+// the Breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+}
+
+// Failure records a request which failed to reach the upstream host,
+// opening the Breaker once there have been Threshold in a row, or
+// immediately if it was a half-open probe
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Status returns a snapshot of the Breaker
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := BreakerStatus{State: b.state.String(), Failures: b.failures}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
@@ -0,0 +1,45 @@
+package apis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Date(2020, 6, 2, 15, 0, 0, 0, time.UTC)
+	b := NewBreaker(BreakerOptions{Threshold: 2, Cooldown: time.Minute})
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	if !b.Allow() {
+		t.Errorf("Breaker opened below its threshold")
+	}
+	b.Failure()
+	if b.Allow() {
+		t.Errorf("Breaker did not open at its threshold")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Errorf("Breaker did not let a probe through after its cooldown")
+	}
+	if b.Allow() {
+		t.Errorf("Breaker let a second probe through")
+	}
+	if state := b.Status().State; state != "half-open" {
+		t.Errorf("State was incorrect, got: %s, want: half-open", state)
+	}
+
+	// a failed probe reopens the breaker
+	b.Failure()
+	if b.Allow() {
+		t.Errorf("Breaker did not reopen after a failed probe")
+	}
+
+	now = now.Add(time.Minute)
+	b.Allow()
+	b.Success()
+	if status := b.Status(); status.State != "closed" || status.Failures != 0 || !b.Allow() {
+		t.Errorf("Breaker did not close after a successful probe, got: %v", status)
+	}
+}
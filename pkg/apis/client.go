@@ -0,0 +1,234 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+)
+
+// Defaults of the shared upstream HTTP client
+const (
+	defaultTimeout   = 10 * time.Second
+	defaultRetries   = 2
+	defaultBackoff   = 250 * time.Millisecond
+	defaultUserAgent = "thorcast-server"
+)
+
+// Maximum number of bytes of an error response body that are kept
+const maxErrorBody = 64 * 1024
+
+// ClientOptions configures a Client
+// Timeout bounds each attempt of a request
+// UserAgent and Contact identify thorcast to upstream apis, as
+// api.weather.gov requires ("UserAgent (Contact)")
+// Retries is the number of times a request failing with a timeout or
+// a 5xx status is retried, waiting a jittered, exponentially growing
+// multiple of Backoff between attempts
+// Breaker configures the circuit breaker kept for each upstream host
+type ClientOptions struct {
+	Timeout   time.Duration
+	UserAgent string
+	Contact   string
+	Retries   int
+	Backoff   time.Duration
+	Breaker   BreakerOptions
+}
+
+// Client is the HTTP client shared by every upstream api
+// Requests to a host fail fast while its Breaker is open
+type Client struct {
+	HTTP      *http.Client
+	UserAgent string
+	Retries   int
+	Backoff   time.Duration
+
+	breakerOpts BreakerOptions
+	mu          sync.Mutex
+	breakers    map[string]*Breaker
+}
+
+// StatusError is the cause of an error from an upstream api responding
+// with an unexpected status code
+// Body holds (the start of) the response body, and Problem the problem
+// it describes, if it is one
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+	Problem    *Problem
+}
+
+func (se *StatusError) Error() string {
+	if se.Problem != nil {
+		return fmt.Sprintf("%s responded with status %d: %s %s", se.URL, se.StatusCode, se.Problem.Title, se.Problem.Detail)
+	}
+	return fmt.Sprintf("%s responded with status %d", se.URL, se.StatusCode)
+}
+
+// IsNotFound reports whether err was caused by an upstream api responding
+// with 404 Not Found
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// NewClient creates a Client, filling in defaults for unset options
+func NewClient(opts ClientOptions) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = defaultUserAgent
+	}
+	if opts.Contact != "" {
+		opts.UserAgent = fmt.Sprintf("%s (%s)", opts.UserAgent, opts.Contact)
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultBackoff
+	}
+	return &Client{
+		HTTP:      &http.Client{Timeout: opts.Timeout},
+		UserAgent: opts.UserAgent,
+		Retries:   opts.Retries,
+		Backoff:   opts.Backoff,
+
+		breakerOpts: opts.Breaker,
+		breakers:    make(map[string]*Breaker),
+	}
+}
+
+// DefaultClient returns a Client with default options
+func DefaultClient() *Client {
+	return NewClient(ClientOptions{Retries: defaultRetries})
+}
+
+// GetJSON requests url and decodes its JSON response body into v
+// Transport failures, timeouts and 5xx statuses are retried, and are
+// errs.ErrUpstreamUnavailable once retries are exhausted
+// Any other status but 200 is an errs.ErrUpstream caused by a *StatusError,
+// holding the Problem of problem+json responses, as is a body that cannot
+// be decoded
+// Requests to a host whose Breaker is open are errs.ErrUpstreamUnavailable
+// caused by ErrCircuitOpen, without being made
+func (c *Client) GetJSON(ctx context.Context, rawURL string, v interface{}) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errs.Wrap(errs.ErrInternal, err)
+	}
+	b := c.breaker(u.Host)
+	if !b.Allow() {
+		return errs.Wrap(errs.ErrUpstreamUnavailable, ErrCircuitOpen)
+	}
+	err = c.getJSONWithRetries(ctx, rawURL, v)
+	if err == nil || !errors.Is(err, errs.ErrUpstreamUnavailable) {
+		b.Success()
+	} else if ctx.Err() == nil {
+		// requests abandoned by the caller say nothing of the host
+		b.Failure()
+	}
+	return err
+}
+
+// BreakerStatus returns a snapshot of the Breaker of every upstream host
+// requested so far, by host
+func (c *Client) BreakerStatus() map[string]BreakerStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := make(map[string]BreakerStatus, len(c.breakers))
+	for host, b := range c.breakers {
+		status[host] = b.Status()
+	}
+	return status
+}
+
+// breaker returns the Breaker of host, creating it on first use
+func (c *Client) breaker(host string) *Breaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[host]
+	if !ok {
+		b = NewBreaker(c.breakerOpts)
+		c.breakers[host] = b
+	}
+	return b
+}
+
+// getJSONWithRetries makes up to Retries + 1 attempts of GetJSON
+func (c *Client) getJSONWithRetries(ctx context.Context, url string, v interface{}) error {
+	var lastErr error
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			if err := c.wait(ctx, attempt); err != nil {
+				return errs.Wrap(errs.ErrUpstreamUnavailable, err)
+			}
+		}
+		retry, err := c.getJSON(ctx, url, v)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			return err
+		}
+		log.Printf("Attempt %d of GET %s failed\nError is: %s\n", attempt+1, url, err.Error())
+	}
+	return lastErr
+}
+
+// getJSON makes a single attempt of GetJSON, reporting whether a failure
+// should be retried
+func (c *Client) getJSON(ctx context.Context, url string, v interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, errs.Wrap(errs.ErrInternal, err)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept", "application/geo+json, application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		// a cancelled request is not worth retrying
+		return ctx.Err() == nil, errs.Wrap(errs.ErrUpstreamUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		statusErr := &StatusError{URL: url, StatusCode: resp.StatusCode, Body: body, Problem: parseProblem(body)}
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return true, errs.Wrap(errs.ErrUpstreamUnavailable, statusErr)
+		}
+		return false, errs.Wrap(errs.ErrUpstream, statusErr)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, errs.Wrap(errs.ErrUpstream, err)
+	}
+	return false, nil
+}
+
+// wait sleeps before retry number attempt, returning early if ctx is done
+// the delay is drawn uniformly from [0, Backoff * 2^(attempt-1))
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	ceiling := c.Backoff << uint(attempt-1)
+	delay := time.Duration(rand.Int63n(int64(ceiling)))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
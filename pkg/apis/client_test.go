@@ -0,0 +1,128 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+)
+
+func newTestClient(retries int) *Client {
+	return NewClient(ClientOptions{
+		Timeout:   time.Second,
+		UserAgent: "thorcast-test",
+		Contact:   "thorcast@example.com",
+		Retries:   retries,
+		Backoff:   time.Millisecond,
+	})
+}
+
+func TestGetJSONRetriesServerErrors(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if ua := r.Header.Get("User-Agent"); ua != "thorcast-test (thorcast@example.com)" {
+			t.Errorf("User-Agent was incorrect, got: %s", ua)
+		}
+		if attempts < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "OK"}`))
+	}))
+	defer srv.Close()
+
+	var body struct{ Status string }
+	err := newTestClient(2).GetJSON(context.Background(), srv.URL, &body)
+
+	if err != nil || body.Status != "OK" || attempts != 3 {
+		t.Errorf("Request was incorrect, got: %v after %d attempts (%v)", body, attempts, err)
+	}
+}
+
+func TestGetJSONGivesUp(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	var body struct{}
+	err := newTestClient(1).GetJSON(context.Background(), srv.URL, &body)
+
+	if !errors.Is(err, errs.ErrUpstreamUnavailable) || attempts != 2 {
+		t.Errorf("Error was incorrect, got: %v after %d attempts", err, attempts)
+	}
+}
+
+func TestGetJSONClientErrorIsNotRetried(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	var body struct{}
+	err := newTestClient(2).GetJSON(context.Background(), srv.URL, &body)
+
+	var statusErr *StatusError
+	if !errors.Is(err, errs.ErrUpstream) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Error was incorrect, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Attempts were incorrect, got: %d, want: 1", attempts)
+	}
+}
+
+func TestGetJSONFailsFastWhileOpen(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewClient(ClientOptions{Backoff: time.Millisecond, Breaker: BreakerOptions{Threshold: 1}})
+	var body struct{}
+	_ = c.GetJSON(context.Background(), srv.URL, &body)
+	err := c.GetJSON(context.Background(), srv.URL, &body)
+
+	if !errors.Is(err, errs.ErrUpstreamUnavailable) || !errors.Is(err, ErrCircuitOpen) || attempts != 1 {
+		t.Errorf("Error was incorrect, got: %v after %d attempts", err, attempts)
+	}
+	for _, status := range c.BreakerStatus() {
+		if status.State != "open" {
+			t.Errorf("State was incorrect, got: %s, want: open", status.State)
+		}
+	}
+}
+
+func TestGetJSONParsesProblems(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{
+			"correlationId": "1a2b3c",
+			"title": "Data Unavailable For Requested Point",
+			"type": "https://api.weather.gov/problems/InvalidPoint",
+			"status": 404,
+			"detail": "Unable to provide data for requested point 51.5074,-0.1278",
+			"instance": "https://api.weather.gov/requests/1a2b3c"
+		}`))
+	}))
+	defer srv.Close()
+
+	var body struct{}
+	err := newTestClient(0).GetJSON(context.Background(), srv.URL, &body)
+
+	p := ProblemOf(err)
+	if p == nil || p.Type != "https://api.weather.gov/problems/InvalidPoint" || p.Status != http.StatusNotFound {
+		t.Errorf("Problem was incorrect, got: %+v (%v)", p, err)
+	}
+}
@@ -0,0 +1,121 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+)
+
+// Product is a text product issued by a National Weather Service office,
+// as returned by api.weather.gov/products/{productId}
+type Product struct {
+	ID            string    `json:"id"`
+	IssuingOffice string    `json:"issuingOffice"`
+	IssuanceTime  time.Time `json:"issuanceTime"`
+	ProductCode   string    `json:"productCode"`
+	ProductName   string    `json:"productName"`
+	ProductText   string    `json:"productText"`
+}
+
+// Products holds data from the request to
+// api.weather.gov/products/types/{typeId}/locations/{locationId}
+// the products are listed latest first, without their text
+type Products struct {
+	Graph []Product `json:"@graph"`
+}
+
+// DiscussionProvider is a source of Area Forecast Discussions
+// FetchDiscussion returns the latest Area Forecast Discussion (AFD)
+// issued by a forecast office
+type DiscussionProvider interface {
+	FetchDiscussion(ctx context.Context, office string) (Product, error)
+}
+
+// FetchDiscussion queries api.weather.gov/products for the latest AFD
+// issued by the given forecast office
+// An office without any is errs.ErrNotFound
+func (wg *WeatherGov) FetchDiscussion(ctx context.Context, office string) (Product, error) {
+	requestURL := fmt.Sprintf("%s/types/AFD/locations/%s", wg.ProductsURL, strings.ToUpper(office))
+	var products Products
+	if err := wg.Client.GetJSON(ctx, requestURL, &products); err != nil {
+		log.Printf("Error fetching products\nError is %s\n", err.Error())
+		return Product{}, err
+	}
+	if len(products.Graph) == 0 {
+		return Product{}, errs.WithDetail(
+			errs.ErrNotFound,
+			fmt.Sprintf("Forecast office %s has not issued an area forecast discussion.", office))
+	}
+	requestURL = fmt.Sprintf("%s/%s", wg.ProductsURL, products.Graph[0].ID)
+	var p Product
+	if err := wg.Client.GetJSON(ctx, requestURL, &p); err != nil {
+		log.Printf("Error fetching product\nError is %s\n", err.Error())
+		return Product{}, err
+	}
+	return p, nil
+}
+
+// DiscussionSection is a section of an Area Forecast Discussion
+// Key identifies the section, e.g. near_term for .NEAR TERM /THROUGH TONIGHT/
+// Name is its heading and Period the span of time it covers, if given
+type DiscussionSection struct {
+	Key    string
+	Name   string
+	Period string
+	Text   string
+}
+
+// Pattern of the heading of a section of an AFD, e.g.
+// .NEAR TERM /THROUGH TONIGHT/...
+// text may follow the heading on the same line
+var sectionPattern = regexp.MustCompile(`^\.([A-Z][A-Z0-9 /&-]*?)\.\.\.(.*)$`)
+
+// Pattern of the characters of a section heading replaced in its Key
+var sectionKeyPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ParseDiscussion splits the text of an AFD into its sections (synopsis,
+// near term, short term, long term, aviation, ...), in order
+// The header of the product before its first section is dropped, and
+// each section ends at the next heading or a && or $$ line
+func ParseDiscussion(text string) []DiscussionSection {
+	var sections []DiscussionSection
+	var current *DiscussionSection
+	var body []string
+	flush := func() {
+		if current != nil {
+			current.Text = strings.TrimSpace(strings.Join(body, "\n"))
+			sections = append(sections, *current)
+		}
+		current, body = nil, nil
+	}
+	for _, line := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n") {
+		if match := sectionPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			name, period := match[1], ""
+			if i := strings.Index(name, " /"); i >= 0 {
+				name, period = name[:i], strings.Trim(name[i+2:], "/ ")
+			}
+			current = &DiscussionSection{
+				Key:    strings.Trim(sectionKeyPattern.ReplaceAllString(strings.ToLower(name), "_"), "_"),
+				Name:   strings.TrimSpace(name),
+				Period: period,
+			}
+			body = []string{match[2]}
+			continue
+		}
+		if trimmed := strings.TrimSpace(line); trimmed == "&&" || trimmed == "$$" {
+			flush()
+			continue
+		}
+		if current != nil {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return sections
+}
@@ -0,0 +1,92 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDiscussion = `000
+FXUS63 KLOT 181130
+AFDLOT
+
+Area Forecast Discussion
+National Weather Service Chicago/Romeoville IL
+630 AM CDT Sat Oct 18 2026
+
+.SYNOPSIS...
+Issued at 630 AM CDT Sat Oct 18 2026
+
+Showers move in this afternoon.
+
+&&
+
+.NEAR TERM /THROUGH TONIGHT/...
+Issued at 630 AM CDT Sat Oct 18 2026
+
+...Key messages...
+A line of storms this evening.
+
+&&
+
+.AVIATION /12Z TAFS THROUGH 12Z SUNDAY/...MVFR ceilings by 18Z.
+
+&&
+
+.LOT WATCHES/WARNINGS/ADVISORIES...
+IL...None.
+&&
+
+$$
+
+Forecaster
+`
+
+func TestParseDiscussion(t *testing.T) {
+	sections := ParseDiscussion(testDiscussion)
+
+	targets := []DiscussionSection{
+		{Key: "synopsis", Name: "SYNOPSIS"},
+		{Key: "near_term", Name: "NEAR TERM", Period: "THROUGH TONIGHT"},
+		{Key: "aviation", Name: "AVIATION", Period: "12Z TAFS THROUGH 12Z SUNDAY", Text: "MVFR ceilings by 18Z."},
+		{Key: "lot_watches_warnings_advisories", Name: "LOT WATCHES/WARNINGS/ADVISORIES", Text: "IL...None."},
+	}
+	if len(sections) != len(targets) {
+		t.Fatalf("Sections were incorrect, got: %+v", sections)
+	}
+	for i, target := range targets {
+		s := sections[i]
+		if s.Key != target.Key || s.Name != target.Name || s.Period != target.Period {
+			t.Errorf("Section %d was incorrect, got: %+v, want: %+v", i, s, target)
+		}
+		if target.Text != "" && s.Text != target.Text {
+			t.Errorf("Section %d text was incorrect, got: %q, want: %q", i, s.Text, target.Text)
+		}
+	}
+	if !strings.HasSuffix(sections[1].Text, "A line of storms this evening.") {
+		t.Errorf("Near term text was incorrect, got: %q", sections[1].Text)
+	}
+}
+
+func TestFetchDiscussion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/products/types/AFD/locations/LOT":
+			_, _ = w.Write([]byte(`{"@graph": [{"id": "abc-123", "issuingOffice": "KLOT"}, {"id": "abc-122"}]}`))
+		case "/products/abc-123":
+			_, _ = w.Write([]byte(`{"id": "abc-123", "issuingOffice": "KLOT", "issuanceTime": "2026-10-18T11:30:00+00:00", "productText": ".SYNOPSIS...Showers.\n&&"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	wg := NewWeatherGov(srv.URL+"/points", newTestClient(0))
+	p, err := wg.FetchDiscussion(context.Background(), "lot")
+
+	if err != nil || p.ID != "abc-123" || !strings.HasPrefix(p.ProductText, ".SYNOPSIS") {
+		t.Errorf("Product was incorrect, got: %+v (%v)", p, err)
+	}
+}
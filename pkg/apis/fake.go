@@ -0,0 +1,142 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+// FakeForecastProvider is an in-memory ForecastProvider for tests
+// and offline development
+// Unregistered forecast and grid data urls respond as if with 404 Not Found
+// Points is keyed by the coordinates of a Location
+// Forecasts is keyed by forecast url (Points.Properties.Forecast
+// or Points.Properties.ForecastHourly), and converted to the System
+// requested as weather.gov would (temperatures only)
+// GridData is keyed by Points.Properties.ForecastGridData
+// It is also a fake AlertProvider; Alerts is keyed by coordinates, and
+// coordinates without any are free of alerts
+// It is also a fake ObservationProvider; Stations is keyed by
+// Points.Properties.ObservationStations and Observations by station id
+// It is also a fake DiscussionProvider; Discussions is keyed by office
+type FakeForecastProvider struct {
+	Points       map[models.Coordinates]Points
+	Forecasts    map[string]Forecasts
+	GridData     map[string]GridData
+	Alerts       map[models.Coordinates]Alerts
+	Stations     map[string]Stations
+	Observations map[string]Observation
+	Discussions  map[string]Product
+}
+
+// NewFakeForecastProvider creates an empty FakeForecastProvider
+func NewFakeForecastProvider() *FakeForecastProvider {
+	return &FakeForecastProvider{
+		Points:       map[models.Coordinates]Points{},
+		Forecasts:    map[string]Forecasts{},
+		GridData:     map[string]GridData{},
+		Alerts:       map[models.Coordinates]Alerts{},
+		Stations:     map[string]Stations{},
+		Observations: map[string]Observation{},
+		Discussions:  map[string]Product{},
+	}
+}
+
+// AddLocation registers Points for the given coordinates along with
+// the detailed and hourly forecasts served at its forecast urls
+func (f *FakeForecastProvider) AddLocation(c models.Coordinates, detailed, hourly Forecasts) Points {
+	var p Points
+	// each location is given a grid cell of its own
+	p.Properties.Cwa = "TST"
+	p.Properties.GridX = len(f.Points) + 1
+	p.Properties.GridY = 1
+	p.Properties.Forecast = fmt.Sprintf("fake://forecast/%f,%f", c.Lat, c.Lng)
+	p.Properties.ForecastHourly = fmt.Sprintf("fake://forecast/%f,%f/hourly", c.Lat, c.Lng)
+	p.Properties.ForecastGridData = fmt.Sprintf("fake://gridpoints/%f,%f", c.Lat, c.Lng)
+	p.Properties.ObservationStations = fmt.Sprintf("fake://gridpoints/%f,%f/stations", c.Lat, c.Lng)
+	f.Points[c] = p
+	f.Forecasts[p.Properties.Forecast] = detailed
+	f.Forecasts[p.Properties.ForecastHourly] = hourly
+	return p
+}
+
+// FetchPoints returns the Points registered for the Location's coordinates
+func (f *FakeForecastProvider) FetchPoints(ctx context.Context, l models.Location) (Points, error) {
+	p, ok := f.Points[models.Coordinates{Lat: l.Lat, Lng: l.Lng}]
+	if !ok {
+		return Points{}, errs.ErrOutOfCoverage
+	}
+	return p, nil
+}
+
+// FetchDetailedForecasts returns the forecasts registered at Points.Properties.Forecast
+func (f *FakeForecastProvider) FetchDetailedForecasts(ctx context.Context, p Points, system units.System) (Forecasts, error) {
+	return f.fetchForecasts(p.Properties.Forecast, system)
+}
+
+// FetchHourlyForecasts returns the forecasts registered at Points.Properties.ForecastHourly
+func (f *FakeForecastProvider) FetchHourlyForecasts(ctx context.Context, p Points, system units.System) (Forecasts, error) {
+	return f.fetchForecasts(p.Properties.ForecastHourly, system)
+}
+
+// FetchGridData returns the grid data registered at Points.Properties.ForecastGridData
+func (f *FakeForecastProvider) FetchGridData(ctx context.Context, p Points) (GridData, error) {
+	g, ok := f.GridData[p.Properties.ForecastGridData]
+	if !ok {
+		return GridData{}, errs.Wrap(errs.ErrUpstream, &StatusError{URL: p.Properties.ForecastGridData, StatusCode: http.StatusNotFound})
+	}
+	return g, nil
+}
+
+// FetchAlerts returns the alerts registered for the coordinates
+func (f *FakeForecastProvider) FetchAlerts(ctx context.Context, c models.Coordinates) (Alerts, error) {
+	return f.Alerts[c], nil
+}
+
+// FetchStations returns the stations registered at Points.Properties.ObservationStations
+func (f *FakeForecastProvider) FetchStations(ctx context.Context, p Points) (Stations, error) {
+	return f.Stations[p.Properties.ObservationStations], nil
+}
+
+// FetchLatestObservation returns the observation registered for the station
+func (f *FakeForecastProvider) FetchLatestObservation(ctx context.Context, stationID string) (Observation, error) {
+	o, ok := f.Observations[stationID]
+	if !ok {
+		return Observation{}, errs.Wrap(errs.ErrUpstream, fmt.Errorf("no observation of station %s", stationID))
+	}
+	return o, nil
+}
+
+// FetchDiscussion returns the AFD registered for the office
+func (f *FakeForecastProvider) FetchDiscussion(ctx context.Context, office string) (Product, error) {
+	p, ok := f.Discussions[office]
+	if !ok {
+		return Product{}, errs.WithDetail(errs.ErrNotFound, fmt.Sprintf("no discussion of office %s", office))
+	}
+	return p, nil
+}
+
+func (f *FakeForecastProvider) fetchForecasts(forecastsURL string, system units.System) (Forecasts, error) {
+	fc, ok := f.Forecasts[forecastsURL]
+	if !ok {
+		return Forecasts{}, errs.Wrap(errs.ErrUpstream, &StatusError{URL: forecastsURL, StatusCode: http.StatusNotFound})
+	}
+	converted := fc
+	converted.Properties.Periods = make([]ForecastPeriod, 0, len(fc.Properties.Periods))
+	for _, p := range fc.Properties.Periods {
+		if p.TemperatureUnit != "" {
+			var unit string
+			p.Temperature, unit = units.Convert(p.Temperature, "deg"+p.TemperatureUnit, system)
+			p.Temperature = math.Round(p.Temperature)
+			p.TemperatureUnit = strings.TrimPrefix(unit, "°")
+		}
+		converted.Properties.Periods = append(converted.Properties.Periods, p)
+	}
+	return converted, nil
+}
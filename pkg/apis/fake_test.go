@@ -0,0 +1,65 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+func TestFakeForecastProviderLookups(t *testing.T) {
+	f := NewFakeForecastProvider()
+	var detailed, hourly Forecasts
+	detailed.Properties.Periods = []ForecastPeriod{{Name: "Today", Temperature: 68, TemperatureUnit: "F"}}
+	hourly.Properties.Periods = []ForecastPeriod{{Temperature: 50, TemperatureUnit: "F"}}
+	chicago := f.AddLocation(models.Coordinates{Lat: 41.8781, Lng: -87.6298}, detailed, hourly)
+	cicero := f.AddLocation(models.Coordinates{Lat: 41.8456, Lng: -87.7539}, Forecasts{}, Forecasts{})
+
+	if chicago.Properties.GridX == cicero.Properties.GridX && chicago.Properties.GridY == cicero.Properties.GridY {
+		t.Errorf("Locations shared a grid cell, got: %d,%d", chicago.Properties.GridX, chicago.Properties.GridY)
+	}
+
+	p, err := f.FetchPoints(context.Background(), models.Location{Lat: 41.8781, Lng: -87.6298})
+	if err != nil || p.Properties.Forecast != chicago.Properties.Forecast {
+		t.Fatalf("Points were incorrect, got: %+v (%v)", p.Properties, err)
+	}
+
+	fc, err := f.FetchDetailedForecasts(context.Background(), p, units.US)
+	if err != nil || len(fc.Properties.Periods) != 1 || fc.Properties.Periods[0].Temperature != 68 {
+		t.Errorf("Detailed forecasts were incorrect, got: %+v (%v)", fc.Properties.Periods, err)
+	}
+	fc, err = f.FetchDetailedForecasts(context.Background(), p, units.SI)
+	if err != nil || fc.Properties.Periods[0].Temperature != 20 || fc.Properties.Periods[0].TemperatureUnit != "C" {
+		t.Errorf("SI forecasts were incorrect, got: %+v (%v)", fc.Properties.Periods, err)
+	}
+	if detailed.Properties.Periods[0].Temperature != 68 {
+		t.Errorf("Registered forecasts were converted in place, got: %v", detailed.Properties.Periods[0].Temperature)
+	}
+	if fc, err = f.FetchHourlyForecasts(context.Background(), p, units.US); err != nil || fc.Properties.Periods[0].Temperature != 50 {
+		t.Errorf("Hourly forecasts were incorrect, got: %+v (%v)", fc.Properties.Periods, err)
+	}
+}
+
+func TestFakeForecastProviderMisses(t *testing.T) {
+	f := NewFakeForecastProvider()
+
+	if _, err := f.FetchPoints(context.Background(), models.Location{Lat: 51.5074, Lng: -0.1278}); !errors.Is(err, errs.ErrOutOfCoverage) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrOutOfCoverage)
+	}
+
+	var p Points
+	p.Properties.Forecast = "fake://forecast/unknown"
+	p.Properties.ForecastGridData = "fake://gridpoints/unknown"
+	if _, err := f.FetchDetailedForecasts(context.Background(), p, units.US); !errors.Is(err, errs.ErrUpstream) || !IsNotFound(err) {
+		t.Errorf("Error was incorrect, got: %v, want: a 404 %v", err, errs.ErrUpstream)
+	}
+	if _, err := f.FetchGridData(context.Background(), p); !IsNotFound(err) {
+		t.Errorf("Error was incorrect, got: %v, want: a 404", err)
+	}
+	if a, err := f.FetchAlerts(context.Background(), models.Coordinates{Lat: 1, Lng: 1}); err != nil || len(a.Features) != 0 {
+		t.Errorf("Alerts were incorrect, got: %+v (%v), want: none", a, err)
+	}
+}
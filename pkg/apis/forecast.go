@@ -1,14 +1,17 @@
 package apis
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/kylep342/thorcast-server/pkg/errs"
 	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
 )
 
 // Root URL for weather.gov's api
@@ -61,6 +64,60 @@ type Points struct {
 	} `json:"properties"`
 }
 
+// GridPoint returns the forecast metadata of the Points for storage,
+// as fetched at updatedAt
+func (p Points) GridPoint(updatedAt time.Time) models.GridPoint {
+	return models.GridPoint{
+		Cwa:                    p.Properties.Cwa,
+		GridX:                  p.Properties.GridX,
+		GridY:                  p.Properties.GridY,
+		ForecastURL:            p.Properties.Forecast,
+		ForecastHourlyURL:      p.Properties.ForecastHourly,
+		ForecastGridDataURL:    p.Properties.ForecastGridData,
+		ObservationStationsURL: p.Properties.ObservationStations,
+		TimeZone:               p.Properties.TimeZone,
+		ForecastZone:           p.Properties.ForecastZone,
+		County:                 p.Properties.County,
+		RadarStation:           p.Properties.RadarStation,
+		UpdatedAt:              updatedAt,
+	}
+}
+
+// PointsFromGridPoint recreates the Points a stored GridPoint was made from
+func PointsFromGridPoint(g models.GridPoint) Points {
+	var p Points
+	p.Properties.Cwa = g.Cwa
+	p.Properties.GridX = g.GridX
+	p.Properties.GridY = g.GridY
+	p.Properties.Forecast = g.ForecastURL
+	p.Properties.ForecastHourly = g.ForecastHourlyURL
+	p.Properties.ForecastGridData = g.ForecastGridDataURL
+	p.Properties.ObservationStations = g.ObservationStationsURL
+	p.Properties.TimeZone = g.TimeZone
+	p.Properties.ForecastZone = g.ForecastZone
+	p.Properties.County = g.County
+	p.Properties.RadarStation = g.RadarStation
+	return p
+}
+
+// ForecastPeriod holds a single period of a forecast, either a day/night
+// period of a detailed forecast or an hour of an hourly forecast
+type ForecastPeriod struct {
+	Number           int         `json:"number"`
+	Name             string      `json:"name"`
+	StartTime        string      `json:"startTime"`
+	EndTime          string      `json:"endTime"`
+	IsDaytime        bool        `json:"isDaytime"`
+	Temperature      float64     `json:"temperature"`
+	TemperatureUnit  string      `json:"temperatureUnit"`
+	TemperatureTrend interface{} `json:"temperatureTrend"`
+	WindSpeed        string      `json:"windSpeed"`
+	WindDirection    string      `json:"windDirection"`
+	Icon             string      `json:"icon"`
+	ShortForecast    string      `json:"shortForecast"`
+	DetailedForecast string      `json:"detailedForecast"`
+}
+
 // Forecasts holds data from the request from the Points.Properties.Forecast url
 type Forecasts struct {
 	Context  []interface{} `json:"@context"`
@@ -80,78 +137,169 @@ type Forecasts struct {
 			Value    float64 `json:"value"`
 			UnitCode string  `json:"unitCode"`
 		} `json:"elevation"`
-		Periods []struct {
-			Number           int         `json:"number"`
-			Name             string      `json:"name"`
-			StartTime        string      `json:"startTime"`
-			EndTime          string      `json:"endTime"`
-			IsDaytime        bool        `json:"isDaytime"`
-			Temperature      float64     `json:"temperature"`
-			TemperatureUnit  string      `json:"temperatureUnit"`
-			TemperatureTrend interface{} `json:"temperatureTrend"`
-			WindSpeed        string      `json:"windSpeed"`
-			WindDirection    string      `json:"windDirection"`
-			Icon             string      `json:"icon"`
-			ShortForecast    string      `json:"shortForecast"`
-			DetailedForecast string      `json:"detailedForecast"`
-		} `json:"periods"`
+		Periods []ForecastPeriod `json:"periods"`
 	} `json:"properties"`
 }
 
-func fetchPoints(l models.Location) (Points, error) {
-	requestURL := fmt.Sprintf("%s/%f,%f", weatherGovAPI, l.Lat, l.Lng)
-	resp, err := http.Get(requestURL)
-	log.Printf("response is %v\n", resp)
-	if err != nil {
-		log.Printf("Error is %s\n", err.Error())
-		return Points{}, err
+// ForecastProvider is a source of forecast data
+// FetchPoints resolves a Location to the forecast metadata for its grid point
+// FetchDetailedForecasts and FetchHourlyForecasts return all periods of
+// the respective forecast for the given Points, in the given System of
+// units (temperatures, wind speeds and forecast text)
+// FetchGridData returns the raw forecast data of the grid point
+// Requests are abandoned when ctx is done
+type ForecastProvider interface {
+	FetchPoints(ctx context.Context, l models.Location) (Points, error)
+	FetchDetailedForecasts(ctx context.Context, p Points, system units.System) (Forecasts, error)
+	FetchHourlyForecasts(ctx context.Context, p Points, system units.System) (Forecasts, error)
+	FetchGridData(ctx context.Context, p Points) (GridData, error)
+}
+
+// WeatherGov is the ForecastProvider, AlertProvider, ObservationProvider
+// and DiscussionProvider backed by api.weather.gov
+// PointsURL is the root URL of the /points endpoint
+// AlertsURL is the URL of the /alerts/active endpoint
+// StationsURL is the root URL of the /stations endpoint
+// ProductsURL is the root URL of the /products endpoint
+type WeatherGov struct {
+	PointsURL   string
+	AlertsURL   string
+	StationsURL string
+	ProductsURL string
+	Client      *Client
+}
+
+// NewWeatherGov creates a WeatherGov provider, defaulting to the
+// WEATHER_GOV_API environment variable when pointsURL is empty and to
+// DefaultClient when client is nil
+// The alerts, stations and products endpoints are found alongside the
+// /points endpoint
+func NewWeatherGov(pointsURL string, client *Client) *WeatherGov {
+	if pointsURL == "" {
+		pointsURL = weatherGovAPI
+	}
+	if client == nil {
+		client = DefaultClient()
 	}
+	root := strings.TrimSuffix(strings.TrimSuffix(pointsURL, "/"), "/points")
+	return &WeatherGov{
+		PointsURL:   pointsURL,
+		AlertsURL:   root + "/alerts/active",
+		StationsURL: root + "/stations",
+		ProductsURL: root + "/products",
+		Client:      client,
+	}
+}
+
+// Types of the problems weather.gov responds to /points requests with
+// InvalidPoint is a point outside of its coverage (e.g. outside of the
+// US), InvalidParameter a malformed point, and UnexpectedProblem a
+// transient failure to look up the grid, which succeeds when retried
+const (
+	problemInvalidPoint     = "https://api.weather.gov/problems/InvalidPoint"
+	problemInvalidParameter = "https://api.weather.gov/problems/InvalidParameter"
+	problemUnexpected       = "https://api.weather.gov/problems/UnexpectedProblem"
+)
+
+// FetchPoints queries api.weather.gov/points for the specified (Lat, Lng) pair,
+// to the 4 decimal places weather.gov accepts
+// Its errors are classified by the type of problem weather.gov responds
+// with, see pointsError
+func (wg *WeatherGov) FetchPoints(ctx context.Context, l models.Location) (Points, error) {
+	requestURL := fmt.Sprintf("%s/%.4f,%.4f", wg.PointsURL, l.Lat, l.Lng)
 	var p Points
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&p)
-	if err != nil {
-		log.Printf("Error is %s\n", err.Error())
-		return Points{}, err
+	if err := wg.Client.GetJSON(ctx, requestURL, &p); err != nil {
+		log.Printf("Error fetching points\nError is %s\n", err.Error())
+		return Points{}, pointsError(l, err)
 	}
 	return p, nil
 }
 
-// FetchDetailedForecastURL extracts the URL for a forecast from the
-// api.weather.gov/points response for the specified (Lat, Lng) pair
-func FetchDetailedForecastURL(l models.Location) (string, error) {
-	point, err := fetchPoints(l)
-	if err != nil {
-		log.Printf("Error caught.\n")
-		return "", err
+// pointsError classifies an error fetching the Points of a Location by the
+// Problem weather.gov responded with, describing it with the problem's
+// detail
+// Points outside of its coverage are errs.ErrOutOfCoverage, malformed
+// points errs.ErrInvalidCoordinates, and transient failures
+// errs.ErrUpstreamUnavailable; other errors are classified by their
+// status, as the Client does
+func pointsError(l models.Location, err error) error {
+	problem := ProblemOf(err)
+	if problem == nil {
+		return err
+	}
+	name := fmt.Sprintf("%.4f, %.4f", l.Lat, l.Lng)
+	if l.City != "" {
+		name = fmt.Sprintf("%s, %s", l.City, l.State)
+	}
+	var e *errs.Error
+	var detail string
+	switch problem.Type {
+	case problemInvalidPoint:
+		e = errs.Wrap(errs.ErrOutOfCoverage, err)
+		detail = fmt.Sprintf("weather.gov has no forecasts for %s", name)
+	case problemInvalidParameter:
+		e = errs.WithParam(errs.Wrap(errs.ErrInvalidCoordinates, err), pointsParam(l))
+		detail = fmt.Sprintf("weather.gov rejected the coordinates of %s", name)
+	case problemUnexpected:
+		e = errs.Wrap(errs.ErrUpstreamUnavailable, err)
+		detail = fmt.Sprintf("weather.gov could not look up the forecast grid of %s", name)
+	default:
+		e = errs.As(err)
+		detail = fmt.Sprintf("weather.gov could not look up %s", name)
+	}
+	if problem.Detail != "" {
+		detail = fmt.Sprintf("%s: %s", detail, problem.Detail)
 	}
-	return point.Properties.Forecast, nil
+	return errs.WithDetail(e, strings.TrimSuffix(detail, ".")+".")
 }
 
-// FetchHourlyForecastURL extracts the URL for an hourly forecast from the
-// api.weather.gov/points response for the specified (Lat, Lng) pair
-func FetchHourlyForecastURL(l models.Location) (string, error) {
-	point, err := fetchPoints(l)
-	if err != nil {
-		log.Printf("Error caught.\n")
-		return "", err
+// pointsParam returns the request parameter a Location was given by:
+// city for geocoded Locations, or else whichever of lat and lng is out
+// of range, defaulting to lat
+func pointsParam(l models.Location) string {
+	switch {
+	case l.City != "":
+		return "city"
+	case l.Lng < -180 || l.Lng > 180:
+		return "lng"
+	default:
+		return "lat"
 	}
-	return point.Properties.ForecastHourly, nil
 }
 
-// FetchForecasts extract all periods of forecasts from the forecast url
-// for a requested city and state
-func FetchForecasts(forecastsURL string) (Forecasts, error) {
-	resp, err := http.Get(forecastsURL)
+// FetchDetailedForecasts extracts all periods of the forecast
+// at the Points.Properties.Forecast url
+func (wg *WeatherGov) FetchDetailedForecasts(ctx context.Context, p Points, system units.System) (Forecasts, error) {
+	return wg.fetchForecasts(ctx, p.Properties.Forecast, system)
+}
+
+// FetchHourlyForecasts extracts all periods of the hourly forecast
+// at the Points.Properties.ForecastHourly url
+func (wg *WeatherGov) FetchHourlyForecasts(ctx context.Context, p Points, system units.System) (Forecasts, error) {
+	return wg.fetchForecasts(ctx, p.Properties.ForecastHourly, system)
+}
+
+// fetchForecasts extract all periods of forecasts from the forecast url
+// weather.gov converts forecasts to the System given by its units
+// query parameter
+// weather.gov briefly serves forecasts without periods while their grid
+// is being updated, which are errs.ErrUpstreamUnavailable
+func (wg *WeatherGov) fetchForecasts(ctx context.Context, forecastsURL string, system units.System) (Forecasts, error) {
+	u, err := url.Parse(forecastsURL)
 	if err != nil {
-		log.Printf("Error is %s\n", err.Error())
-		return Forecasts{}, err
+		return Forecasts{}, errs.Wrap(errs.ErrUpstream, err)
 	}
+	q := u.Query()
+	q.Set("units", string(system))
+	u.RawQuery = q.Encode()
+	forecastsURL = u.String()
 	var forecasts Forecasts
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&forecasts)
-	if err != nil {
-		log.Printf("Error when decoding json to Forecasts.\nError is %s\n", err.Error())
+	if err := wg.Client.GetJSON(ctx, forecastsURL, &forecasts); err != nil {
+		log.Printf("Error fetching forecasts.\nError is %s\n", err.Error())
 		return Forecasts{}, err
 	}
+	if len(forecasts.Properties.Periods) == 0 {
+		return Forecasts{}, errs.Wrap(errs.ErrUpstreamUnavailable, fmt.Errorf("%s has no forecast periods", forecastsURL))
+	}
 	return forecasts, nil
 }
@@ -0,0 +1,102 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+func TestFetchPointsPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/points/41.8781,-87.6298" {
+			t.Errorf("Path was incorrect, got: %s, want: /points/41.8781,-87.6298", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"properties": {"cwa": "LOT"}}`))
+	}))
+	defer srv.Close()
+
+	wg := NewWeatherGov(srv.URL+"/points", newTestClient(0))
+	if _, err := wg.FetchPoints(context.Background(), models.Location{Lat: 41.8781, Lng: -87.6298}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestFetchPointsProblems(t *testing.T) {
+	cases := []struct {
+		status  int
+		problem string
+		want    *errs.Error
+	}{
+		{http.StatusNotFound, `{"title": "Data Unavailable For Requested Point", "type": "https://api.weather.gov/problems/InvalidPoint", "status": 404, "detail": "Unable to provide data for requested point 51.5074,-0.1278"}`, errs.ErrOutOfCoverage},
+		{http.StatusBadRequest, `{"title": "Invalid Parameter", "type": "https://api.weather.gov/problems/InvalidParameter", "status": 400, "detail": "Parameter \"point\" is invalid"}`, errs.ErrInvalidCoordinates},
+		{http.StatusInternalServerError, `{"title": "Unexpected Problem", "type": "https://api.weather.gov/problems/UnexpectedProblem", "status": 500, "detail": "An unexpected problem has occurred."}`, errs.ErrUpstreamUnavailable},
+		{http.StatusNotFound, `{"title": "Not Found", "type": "https://api.weather.gov/problems/NotFound", "status": 404, "detail": "The requested resource was not found"}`, errs.ErrUpstream},
+	}
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(c.status)
+			_, _ = w.Write([]byte(c.problem))
+		}))
+
+		wg := NewWeatherGov(srv.URL+"/points", newTestClient(0))
+		_, err := wg.FetchPoints(context.Background(), models.Location{City: "London", State: "UK", Lat: 51.5074, Lng: -0.1278})
+		srv.Close()
+
+		detail := errs.As(err).Detail
+		if errs.As(err).Code != c.want.Code || !strings.Contains(detail, "London, UK") || !strings.Contains(detail, ProblemOf(err).Detail) {
+			t.Errorf("Error was incorrect, got: %v (%q), want: %v", err, detail, c.want)
+		}
+	}
+}
+
+func TestFetchPointsInvalidParameterParam(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"title": "Invalid Parameter", "type": "https://api.weather.gov/problems/InvalidParameter", "status": 400}`))
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		location models.Location
+		want     string
+	}{
+		{models.Location{City: "Chicago", State: "IL", Lat: 41.8781, Lng: -87.6298}, "city"},
+		{models.Location{Lat: 41.8781, Lng: -87.6298}, "lat"},
+		{models.Location{Lat: 41.8781, Lng: -187.6298}, "lng"},
+	}
+	wg := NewWeatherGov(srv.URL+"/points", newTestClient(0))
+	for _, c := range cases {
+		_, err := wg.FetchPoints(context.Background(), c.location)
+		if param := errs.As(err).Param; param != c.want {
+			t.Errorf("Param was incorrect for %s, got: %q, want: %q", c.location.Name(), param, c.want)
+		}
+	}
+}
+
+func TestFetchForecastsWithoutPeriods(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if system := r.URL.Query().Get("units"); system != "si" {
+			t.Errorf("Units were incorrect, got: %q, want: si", system)
+		}
+		_, _ = w.Write([]byte(`{"properties": {"periods": []}}`))
+	}))
+	defer srv.Close()
+
+	wg := NewWeatherGov(srv.URL+"/points", newTestClient(0))
+	var p Points
+	p.Properties.Forecast = srv.URL + "/gridpoints/LOT/76,73/forecast"
+	_, err := wg.FetchDetailedForecasts(context.Background(), p, units.SI)
+
+	if !errors.Is(err, errs.ErrUpstreamUnavailable) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrUpstreamUnavailable)
+	}
+}
@@ -1,16 +1,55 @@
-package main
+package apis
 
 import (
-	"encoding/json"
+	"bufio"
+	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
 )
 
-// const gcAPI = os.Getenv("GOOGLE_MAPS_API")
-var gcAPI = os.Getenv("GOOGLE_MAPS_API")
+// Default URL for the maps.google.com geocoding api
+const defaultGoogleMapsAPI = "https://maps.googleapis.com/maps/api/geocode/json"
+
+// ErrLocationNotFound is returned by a Geocoder that cannot resolve a location
+var ErrLocationNotFound = errs.ErrLocationNotFound
+
+// Confidence assigned to each maps.google.com location_type
+var googleConfidence = map[string]float64{
+	"ROOFTOP":            1.0,
+	"RANGE_INTERPOLATED": 0.9,
+	"GEOMETRIC_CENTER":   0.8,
+	"APPROXIMATE":        0.7,
+}
+
+// Suffixes the Census Bureau appends to place names in its gazetteer files
+var gazetteerSuffixes = []string{" city", " town", " village", " borough", " municipality", " CDP"}
+
+// GeocodeResult holds the coordinates a Geocoder resolved for a location
+// Name is the geocoder's canonical name for the location
+// Confidence ranges from 0 (a guess) to 1 (an exact match)
+type GeocodeResult struct {
+	Coordinates models.Coordinates
+	Name        string
+	Confidence  float64
+}
+
+// Geocoder resolves a city and state to coordinates
+// city is the display name of the city (e.g. "Salt Lake City")
+// state is a 2 character postal code
+// Requests are abandoned when ctx is done
+type Geocoder interface {
+	Geocode(ctx context.Context, city, state string) (GeocodeResult, error)
+}
 
 // Struct holding data from the maps.google.com geocoding api
 type geocodeAPIResp struct {
@@ -45,38 +84,204 @@ type geocodeAPIResp struct {
 	Status string `json:"status"`
 }
 
-// Coordinates holds the lat, lng pair from a maps.google.com geocode api response
-type Coordinates struct {
-	Lat float64 `json:"lat"`
-	Lng float64 `json:"lng"`
+// GoogleGeocoder is the Geocoder backed by the maps.google.com geocoding api
+type GoogleGeocoder struct {
+	APIURL string
+	APIKey string
+	Client *Client
 }
 
-// FetchCoords returns coordinates for a given address
-func FetchCoords(city, state string) (Coordinates, error) {
-	APIKey := os.Getenv("GOOGLE_MAPS_API_KEY")
-	requestURL := fmt.Sprintf("%s?address=%s,%s&key=%s", gcAPI, city, state, APIKey)
-	resp, err := http.Get(requestURL)
-	if err != nil {
-		log.Printf("Google Maps API error is: %s\n", err.Error())
+// NewGoogleGeocoder creates a GoogleGeocoder, defaulting to the public
+// geocoding api url when apiURL is empty and to DefaultClient when
+// client is nil
+func NewGoogleGeocoder(apiURL, apiKey string, client *Client) *GoogleGeocoder {
+	if apiURL == "" {
+		apiURL = defaultGoogleMapsAPI
+	}
+	if client == nil {
+		client = DefaultClient()
 	}
+	return &GoogleGeocoder{APIURL: apiURL, APIKey: apiKey, Client: client}
+}
+
+// Geocode returns coordinates for a given city and state
+func (g *GoogleGeocoder) Geocode(ctx context.Context, city, state string) (GeocodeResult, error) {
+	requestURL := fmt.Sprintf(
+		"%s?address=%s&key=%s",
+		g.APIURL,
+		url.QueryEscape(fmt.Sprintf("%s,%s", city, state)),
+		url.QueryEscape(g.APIKey))
 	var geocode geocodeAPIResp
-	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&geocode)
-	if err != nil {
-		log.Printf("JSON decoding error is: %s\n", err.Error())
+	if err := g.Client.GetJSON(ctx, requestURL, &geocode); err != nil {
+		log.Printf("Google Maps API error is: %s\n", err.Error())
+		return GeocodeResult{}, err
 	}
-	log.Printf("Status is %s\n", geocode.Status)
 	if geocode.Status != "OK" {
+		log.Printf("Status is %s\n", geocode.Status)
 		switch geocode.Status {
 		case "ZERO_RESULTS":
-			return Coordinates{}, errors.New("location not found")
+			return GeocodeResult{}, ErrLocationNotFound
 		default:
-			return Coordinates{}, errors.New("internal error")
+			return GeocodeResult{}, errs.Wrap(errs.ErrUpstream, fmt.Errorf("geocoding status %s", geocode.Status))
 		}
 	}
-	if geocode.Results[0].PartialMatch {
-		return Coordinates{}, errors.New("location not found")
+	result := geocode.Results[0]
+	if result.PartialMatch {
+		return GeocodeResult{}, ErrLocationNotFound
+	}
+	return GeocodeResult{
+		Coordinates: models.Coordinates{
+			Lat: result.Geometry.Location.Lat,
+			Lng: result.Geometry.Location.Lng},
+		Name:       result.FormattedAddress,
+		Confidence: googleConfidence[result.Geometry.LocationType]}, nil
+}
+
+// GazetteerGeocoder is an offline Geocoder backed by a gazetteer file
+// Two delimited formats are accepted, chosen by the header row:
+// the Census Bureau places gazetteer (tab separated; USPS, NAME, INTPTLAT, INTPTLONG)
+// or a plain csv with the columns city, state, lat, lng
+type GazetteerGeocoder struct {
+	places map[string]GeocodeResult
+}
+
+// NewGazetteerGeocoder creates a GazetteerGeocoder from the file at path
+func NewGazetteerGeocoder(path string) (*GazetteerGeocoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadGazetteer(f)
+}
+
+// ReadGazetteer creates a GazetteerGeocoder from gazetteer data
+func ReadGazetteer(r io.Reader) (*GazetteerGeocoder, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	delim := ','
+	if strings.Contains(header, "\t") {
+		delim = '\t'
+	}
+	columns := map[string]int{}
+	for i, name := range strings.Split(strings.TrimSpace(header), string(delim)) {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	cityCol, stateCol, latCol, lngCol, err := gazetteerColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+	// only Census files name places by NAME, with their type appended
+	_, plain := columns["city"]
+	cr := csv.NewReader(br)
+	cr.Comma = delim
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	g := &GazetteerGeocoder{places: map[string]GeocodeResult{}}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) <= cityCol || len(record) <= stateCol || len(record) <= latCol || len(record) <= lngCol {
+			continue
+		}
+		lat, err := strconv.ParseFloat(strings.TrimSpace(record[latCol]), 64)
+		if err != nil {
+			continue
+		}
+		lng, err := strconv.ParseFloat(strings.TrimSpace(record[lngCol]), 64)
+		if err != nil {
+			continue
+		}
+		city := strings.TrimSpace(record[cityCol])
+		if !plain {
+			city = trimPlaceType(city)
+		}
+		state := strings.ToUpper(strings.TrimSpace(record[stateCol]))
+		key := gazetteerKey(city, state)
+		if _, ok := g.places[key]; ok {
+			continue
+		}
+		g.places[key] = GeocodeResult{
+			Coordinates: models.Coordinates{Lat: lat, Lng: lng},
+			Name:        fmt.Sprintf("%s, %s", city, state),
+			Confidence:  1.0}
+	}
+	return g, nil
+}
+
+// trimPlaceType strips the place type the Census Bureau appends to a
+// place name, in any case, e.g. "Chicago city" is Chicago
+func trimPlaceType(name string) string {
+	for _, suffix := range gazetteerSuffixes {
+		if strings.HasSuffix(strings.ToLower(name), strings.ToLower(suffix)) {
+			name = name[:len(name)-len(suffix)]
+		}
+	}
+	return name
+}
+
+func gazetteerColumns(columns map[string]int) (int, int, int, int, error) {
+	indices := make([]int, 4)
+	for i, names := range [][]string{
+		{"city", "name"},
+		{"state", "usps"},
+		{"lat", "intptlat"},
+		{"lng", "intptlong"},
+	} {
+		found := false
+		for _, name := range names {
+			if idx, ok := columns[name]; ok {
+				indices[i] = idx
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, 0, 0, 0, fmt.Errorf("gazetteer is missing a %s column", names[0])
+		}
+	}
+	return indices[0], indices[1], indices[2], indices[3], nil
+}
+
+func gazetteerKey(city, state string) string {
+	return fmt.Sprintf("%s|%s", strings.ToLower(strings.Join(strings.Fields(city), " ")), strings.ToUpper(state))
+}
+
+// Geocode returns coordinates for a given city and state
+func (g *GazetteerGeocoder) Geocode(ctx context.Context, city, state string) (GeocodeResult, error) {
+	result, ok := g.places[gazetteerKey(city, state)]
+	if !ok {
+		return GeocodeResult{}, ErrLocationNotFound
+	}
+	return result, nil
+}
+
+// GeocoderChain is a Geocoder that tries each of its Geocoders in order
+// and returns the first successful result
+// ErrLocationNotFound is returned only if every Geocoder failed to find the
+// location; otherwise the last unexpected error is returned
+type GeocoderChain []Geocoder
+
+// Geocode returns coordinates for a given city and state
+func (gc GeocoderChain) Geocode(ctx context.Context, city, state string) (GeocodeResult, error) {
+	var err error = ErrLocationNotFound
+	for _, g := range gc {
+		result, gErr := g.Geocode(ctx, city, state)
+		if gErr == nil {
+			return result, nil
+		}
+		if !errors.Is(gErr, ErrLocationNotFound) {
+			log.Printf("Geocoder %T failed for %s, %s\nError is: %s\n", g, city, state, gErr.Error())
+			err = gErr
+		}
 	}
-	coords := Coordinates{Lat: geocode.Results[0].Geometry.Location.Lat, Lng: geocode.Results[0].Geometry.Location.Lng}
-	return coords, nil
+	return GeocodeResult{}, err
 }
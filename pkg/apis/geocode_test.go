@@ -0,0 +1,95 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kylep342/thorcast-server/pkg/models"
+)
+
+const csvGazetteer = `city,state,lat,lng
+Chicago,IL,41.8781,-87.6298
+Salt Lake City,UT,40.7608,-111.8910
+Carson City,NV,39.1638,-119.7674
+`
+
+const censusGazetteer = "USPS\tGEOID\tANSICODE\tNAME\tLSAD\tFUNCSTAT\tALAND\tAWATER\tALAND_SQMI\tAWATER_SQMI\tINTPTLAT\tINTPTLONG\n" +
+	"IL\t1714000\t00428803\tChicago city\t25\tA\t589574148\t17045012\t227.636\t6.581\t41.837551\t-87.681844\n" +
+	"MN\t2718852\t02394671\tEly City\t25\tA\t7638422\t0\t2.949\t0.000\t47.903166\t-91.859234\n"
+
+type failingGeocoder struct{}
+
+func (failingGeocoder) Geocode(ctx context.Context, city, state string) (GeocodeResult, error) {
+	return GeocodeResult{}, errors.New("internal error")
+}
+
+func TestGazetteerCSV(t *testing.T) {
+	g, err := ReadGazetteer(strings.NewReader(csvGazetteer))
+	if err != nil {
+		t.Fatalf("Unexpected error reading gazetteer: %s", err.Error())
+	}
+
+	result, err := g.Geocode(context.Background(), "salt  lake city", "ut")
+
+	target := models.Coordinates{Lat: 40.7608, Lng: -111.8910}
+
+	if err != nil || result.Coordinates != target {
+		t.Errorf("Coordinates were incorrect, got: %v (%v), want: %v", result.Coordinates, err, target)
+	}
+	if result.Name != "Salt Lake City, UT" {
+		t.Errorf("Name was incorrect, got: %s, want: Salt Lake City, UT", result.Name)
+	}
+
+	// place types are only trimmed from Census names
+	if result, err := g.Geocode(context.Background(), "Carson City", "NV"); err != nil || result.Name != "Carson City, NV" {
+		t.Errorf("Name was incorrect, got: %s (%v), want: Carson City, NV", result.Name, err)
+	}
+}
+
+func TestGazetteerCensus(t *testing.T) {
+	g, err := ReadGazetteer(strings.NewReader(censusGazetteer))
+	if err != nil {
+		t.Fatalf("Unexpected error reading gazetteer: %s", err.Error())
+	}
+
+	result, err := g.Geocode(context.Background(), "Chicago", "IL")
+
+	target := models.Coordinates{Lat: 41.837551, Lng: -87.681844}
+
+	if err != nil || result.Coordinates != target {
+		t.Errorf("Coordinates were incorrect, got: %v (%v), want: %v", result.Coordinates, err, target)
+	}
+
+	if result, err := g.Geocode(context.Background(), "Ely", "MN"); err != nil || result.Name != "Ely, MN" {
+		t.Errorf("Name was incorrect, got: %s (%v), want: Ely, MN", result.Name, err)
+	}
+}
+
+func TestGazetteerNotFound(t *testing.T) {
+	g, _ := ReadGazetteer(strings.NewReader(csvGazetteer))
+
+	_, err := g.Geocode(context.Background(), "Gotham", "NY")
+
+	if !errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, ErrLocationNotFound)
+	}
+}
+
+func TestGeocoderChainFallback(t *testing.T) {
+	g, _ := ReadGazetteer(strings.NewReader(csvGazetteer))
+	chain := GeocoderChain{failingGeocoder{}, g}
+
+	result, err := chain.Geocode(context.Background(), "Chicago", "IL")
+
+	if err != nil || result.Coordinates.Lat != 41.8781 {
+		t.Errorf("Chain did not fall back, got: %v (%v)", result, err)
+	}
+
+	_, err = chain.Geocode(context.Background(), "Gotham", "NY")
+
+	if err == nil || errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("Chain error was incorrect, got: %v, want: internal error", err)
+	}
+}
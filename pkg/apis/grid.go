@@ -0,0 +1,134 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GridValue is a value of a GridLayer over a span of time
+// ValidTime is an ISO-8601 interval, e.g. 2020-06-02T15:00:00+00:00/PT3H
+// Value is nil where weather.gov has no value
+type GridValue struct {
+	ValidTime string   `json:"validTime"`
+	Value     *float64 `json:"value"`
+}
+
+// GridLayer is a time series of raw gridpoint forecast data
+// Uom is the unit of measure of its values, e.g. wmoUnit:degC
+type GridLayer struct {
+	Uom    string      `json:"uom"`
+	Values []GridValue `json:"values"`
+}
+
+// GridData holds data from the request from the
+// Points.Properties.ForecastGridData url
+type GridData struct {
+	Properties struct {
+		UpdateTime                 time.Time `json:"updateTime"`
+		ValidTimes                 string    `json:"validTimes"`
+		Temperature                GridLayer `json:"temperature"`
+		Dewpoint                   GridLayer `json:"dewpoint"`
+		RelativeHumidity           GridLayer `json:"relativeHumidity"`
+		SkyCover                   GridLayer `json:"skyCover"`
+		ProbabilityOfPrecipitation GridLayer `json:"probabilityOfPrecipitation"`
+		QuantitativePrecipitation  GridLayer `json:"quantitativePrecipitation"`
+		SnowfallAmount             GridLayer `json:"snowfallAmount"`
+		WindGust                   GridLayer `json:"windGust"`
+	} `json:"properties"`
+}
+
+// HourlyValue is the value of a GridLayer for the hour starting at Time
+type HourlyValue struct {
+	Time  time.Time
+	Value *float64
+}
+
+// FetchGridData retrieves the raw gridpoint forecast data
+// at the Points.Properties.ForecastGridData url
+func (wg *WeatherGov) FetchGridData(ctx context.Context, p Points) (GridData, error) {
+	var g GridData
+	if err := wg.Client.GetJSON(ctx, p.Properties.ForecastGridData, &g); err != nil {
+		log.Printf("Error fetching grid data\nError is %s\n", err.Error())
+		return GridData{}, err
+	}
+	return g, nil
+}
+
+// Unit returns the unit of measure of the layer without its namespace
+// e.g. degC for wmoUnit:degC
+func (gl GridLayer) Unit() string {
+	return gl.Uom[strings.LastIndex(gl.Uom, ":")+1:]
+}
+
+// Hourly expands the values of the layer into one value per hour
+// Values of accumulated quantities (e.g. precipitation amounts) are
+// spread evenly over the hours of their interval, any others are
+// repeated for each hour
+func (gl GridLayer) Hourly(accumulated bool) ([]HourlyValue, error) {
+	var hourly []HourlyValue
+	for _, v := range gl.Values {
+		start, duration, err := ParseValidTime(v.ValidTime)
+		if err != nil {
+			return nil, err
+		}
+		hours := int(duration / time.Hour)
+		if hours < 1 {
+			hours = 1
+		}
+		value := v.Value
+		if accumulated && value != nil {
+			perHour := *value / float64(hours)
+			value = &perHour
+		}
+		for i := 0; i < hours; i++ {
+			hourly = append(hourly, HourlyValue{Time: start.Add(time.Duration(i) * time.Hour), Value: value})
+		}
+	}
+	return hourly, nil
+}
+
+// ParseValidTime parses an ISO-8601 interval of a start time and
+// a duration, as used by weather.gov, e.g. 2020-06-02T15:00:00+00:00/P1DT3H
+func ParseValidTime(validTime string) (time.Time, time.Duration, error) {
+	parts := strings.SplitN(validTime, "/", 2)
+	if len(parts) != 2 {
+		return time.Time{}, 0, fmt.Errorf("invalid validTime %q", validTime)
+	}
+	start, err := time.Parse(time.RFC3339, parts[0])
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid validTime %q: %w", validTime, err)
+	}
+	duration, err := ParseDuration(parts[1])
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid validTime %q: %w", validTime, err)
+	}
+	return start.UTC(), duration, nil
+}
+
+// Pattern of the ISO-8601 durations used by weather.gov
+// years and months are never used, as their length varies
+var durationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseDuration parses an ISO-8601 duration of days, hours, minutes
+// and seconds, e.g. P1DT3H
+func ParseDuration(duration string) (time.Duration, error) {
+	match := durationPattern.FindStringSubmatch(duration)
+	if match == nil || duration == "P" || strings.HasSuffix(duration, "T") {
+		return 0, fmt.Errorf("invalid duration %q", duration)
+	}
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(match[i+1])
+		d += time.Duration(n) * unit
+	}
+	return d, nil
+}
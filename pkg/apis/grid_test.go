@@ -0,0 +1,59 @@
+package apis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"PT1H":     time.Hour,
+		"PT3H":     3 * time.Hour,
+		"P1D":      24 * time.Hour,
+		"P1DT6H":   30 * time.Hour,
+		"PT1H30M":  90 * time.Minute,
+		"P7DT0H0M": 7 * 24 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := ParseDuration(in)
+		if err != nil || got != want {
+			t.Errorf("ParseDuration(%q) was incorrect, got: %v (%v), want: %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "P", "PT", "1H", "P1Y", "PT1.5H"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q) did not fail", in)
+		}
+	}
+}
+
+func TestGridLayerHourly(t *testing.T) {
+	six, ten := 6.0, 10.0
+	gl := GridLayer{
+		Uom: "wmoUnit:mm",
+		Values: []GridValue{
+			{ValidTime: "2020-06-02T12:00:00+00:00/PT3H", Value: &six},
+			{ValidTime: "2020-06-02T15:00:00+00:00/PT2H", Value: &ten},
+			{ValidTime: "2020-06-02T17:00:00+00:00/PT1H", Value: nil},
+		},
+	}
+
+	hourly, err := gl.Hourly(true)
+	if err != nil || len(hourly) != 6 {
+		t.Fatalf("Hourly values were incorrect, got: %d (%v), want: 6", len(hourly), err)
+	}
+	if *hourly[0].Value != 2 || *hourly[4].Value != 5 || hourly[5].Value != nil {
+		t.Errorf("Accumulated values were incorrect, got: %v, %v, %v", *hourly[0].Value, *hourly[4].Value, hourly[5].Value)
+	}
+	if want := time.Date(2020, 6, 2, 16, 0, 0, 0, time.UTC); !hourly[4].Time.Equal(want) {
+		t.Errorf("Time was incorrect, got: %v, want: %v", hourly[4].Time, want)
+	}
+
+	hourly, _ = gl.Hourly(false)
+	if *hourly[2].Value != 6 {
+		t.Errorf("Value was incorrect, got: %v, want: 6", *hourly[2].Value)
+	}
+	if gl.Unit() != "mm" {
+		t.Errorf("Unit was incorrect, got: %s, want: mm", gl.Unit())
+	}
+}
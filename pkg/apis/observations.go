@@ -0,0 +1,108 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Measurement is a quantity measured by an observation station
+// UnitCode is the unit of Value, e.g. wmoUnit:degC
+// Value is nil when the station did not report it
+type Measurement struct {
+	UnitCode       string   `json:"unitCode"`
+	Value          *float64 `json:"value"`
+	QualityControl string   `json:"qualityControl"`
+}
+
+// Station is an observation station
+type Station struct {
+	Geometry struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		StationIdentifier string `json:"stationIdentifier"`
+		Name              string `json:"name"`
+		TimeZone          string `json:"timeZone"`
+	} `json:"properties"`
+}
+
+// Stations holds data from the request to the
+// Points.Properties.ObservationStations url
+type Stations struct {
+	Features []Station `json:"features"`
+}
+
+// Observation holds data from the request to
+// api.weather.gov/stations/{stationId}/observations/latest
+type Observation struct {
+	Properties struct {
+		Station            string      `json:"station"`
+		Timestamp          time.Time   `json:"timestamp"`
+		TextDescription    string      `json:"textDescription"`
+		Icon               string      `json:"icon"`
+		Temperature        Measurement `json:"temperature"`
+		Dewpoint           Measurement `json:"dewpoint"`
+		WindDirection      Measurement `json:"windDirection"`
+		WindSpeed          Measurement `json:"windSpeed"`
+		WindGust           Measurement `json:"windGust"`
+		BarometricPressure Measurement `json:"barometricPressure"`
+		SeaLevelPressure   Measurement `json:"seaLevelPressure"`
+		Visibility         Measurement `json:"visibility"`
+		RelativeHumidity   Measurement `json:"relativeHumidity"`
+	} `json:"properties"`
+}
+
+// ObservationProvider is a source of observed weather conditions
+// FetchStations returns the observation stations near the given Points
+// FetchLatestObservation returns the latest observation of a station
+type ObservationProvider interface {
+	FetchStations(ctx context.Context, p Points) (Stations, error)
+	FetchLatestObservation(ctx context.Context, stationID string) (Observation, error)
+}
+
+// FetchStations retrieves the observation stations
+// at the Points.Properties.ObservationStations url
+func (wg *WeatherGov) FetchStations(ctx context.Context, p Points) (Stations, error) {
+	var s Stations
+	if err := wg.Client.GetJSON(ctx, p.Properties.ObservationStations, &s); err != nil {
+		log.Printf("Error fetching stations\nError is %s\n", err.Error())
+		return Stations{}, err
+	}
+	return s, nil
+}
+
+// FetchLatestObservation queries
+// api.weather.gov/stations/{stationId}/observations/latest
+func (wg *WeatherGov) FetchLatestObservation(ctx context.Context, stationID string) (Observation, error) {
+	requestURL := fmt.Sprintf("%s/%s/observations/latest", wg.StationsURL, stationID)
+	var o Observation
+	if err := wg.Client.GetJSON(ctx, requestURL, &o); err != nil {
+		log.Printf("Error fetching latest observation\nError is %s\n", err.Error())
+		return Observation{}, err
+	}
+	return o, nil
+}
+
+// Pressure returns the barometric pressure of the observation, or its
+// sea level pressure if the station did not report the former
+func (o Observation) Pressure() Measurement {
+	if o.Properties.BarometricPressure.Value == nil {
+		return o.Properties.SeaLevelPressure
+	}
+	return o.Properties.BarometricPressure
+}
+
+// IsComplete reports whether the observation has values for the
+// current conditions thorcast reports
+func (o Observation) IsComplete() bool {
+	p := o.Properties
+	for _, m := range []Measurement{p.Temperature, p.WindSpeed, p.RelativeHumidity, o.Pressure(), p.Visibility} {
+		if m.Value == nil {
+			return false
+		}
+	}
+	return p.TextDescription != ""
+}
@@ -0,0 +1,39 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Problem is an error response of weather.gov, in the
+// application/problem+json format of RFC 7807
+// Type identifies the kind of problem, e.g.
+// https://api.weather.gov/problems/InvalidPoint
+type Problem struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail"`
+	Instance      string `json:"instance"`
+	CorrelationID string `json:"correlationId"`
+}
+
+// parseProblem parses an error response body, returning nil if it is
+// not a problem
+func parseProblem(body []byte) *Problem {
+	var p Problem
+	if err := json.Unmarshal(body, &p); err != nil || (p.Type == "" && p.Title == "") {
+		return nil
+	}
+	return &p
+}
+
+// ProblemOf returns the Problem an upstream api responded with to cause
+// err, or nil if there is none
+func ProblemOf(err error) *Problem {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return nil
+	}
+	return statusErr.Problem
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis"
 
@@ -14,10 +16,17 @@ import (
 	"github.com/gorilla/mux"
 
 	_ "github.com/jackc/pgx/stdlib"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/db"
+	"github.com/kylep342/thorcast-server/pkg/service"
 )
 
 // global config struct holding database connection info
 type config struct {
+	sqlDriver     string
+	sqlitePath    string
 	sqlUsername   string
 	sqlPassword   string
 	sqlHost       string
@@ -27,10 +36,25 @@ type config struct {
 	redisHost     string
 	redisPort     string
 	redisDb       int
+	cacheBackend  string
+	cacheSize     int
+	weatherGovAPI string
+	googleMapsAPI string
+	googleMapsKey string
+	geocoders     []string
+	gazetteerFile string
+	httpTimeout   time.Duration
+	httpRetries   int
+	userAgent     string
+	contact       string
+	breaker       apis.BreakerOptions
 }
 
 // method to initialize config struct from environment variables
 func (conf *config) configure() {
+	var err error
+	conf.sqlDriver = os.Getenv("THORCAST_DB_DRIVER")
+	conf.sqlitePath = os.Getenv("THORCAST_SQLITE_PATH")
 	conf.sqlUsername = os.Getenv("THORCAST_DB_USERNAME")
 	conf.sqlPassword = os.Getenv("THORCAST_DB_PASSWORD")
 	conf.sqlHost = os.Getenv("THORCAST_DB_HOST")
@@ -40,6 +64,22 @@ func (conf *config) configure() {
 	conf.redisHost = os.Getenv("REDIS_HOST")
 	conf.redisPort = os.Getenv("REDIS_PORT")
 	conf.redisDb, _ = strconv.Atoi(os.Getenv("REDIS_DB"))
+	conf.cacheBackend = os.Getenv("THORCAST_CACHE")
+	conf.cacheSize, _ = strconv.Atoi(os.Getenv("THORCAST_CACHE_SIZE"))
+	conf.weatherGovAPI = os.Getenv("WEATHER_GOV_API")
+	conf.googleMapsAPI = os.Getenv("GOOGLE_MAPS_API")
+	conf.googleMapsKey = os.Getenv("GOOGLE_MAPS_API_KEY")
+	conf.geocoders = strings.Split(os.Getenv("THORCAST_GEOCODERS"), ",")
+	conf.gazetteerFile = os.Getenv("THORCAST_GAZETTEER_FILE")
+	conf.httpTimeout, _ = time.ParseDuration(os.Getenv("THORCAST_HTTP_TIMEOUT"))
+	conf.httpRetries, err = strconv.Atoi(os.Getenv("THORCAST_HTTP_RETRIES"))
+	if err != nil {
+		conf.httpRetries = 2
+	}
+	conf.userAgent = os.Getenv("THORCAST_USER_AGENT")
+	conf.contact = os.Getenv("THORCAST_CONTACT")
+	conf.breaker.Threshold, _ = strconv.Atoi(os.Getenv("THORCAST_BREAKER_THRESHOLD"))
+	conf.breaker.Cooldown, _ = time.ParseDuration(os.Getenv("THORCAST_BREAKER_COOLDOWN"))
 }
 
 var conf = config{}
@@ -47,45 +87,150 @@ var conf = config{}
 // App contains necessary components to run the webserver
 // Router is a pointer to a mux Router
 // Logger is an http handler
-// DB is a pointer to a db
-// Redis is a pointer to a redis client
+// Locations stores geocoded locations
+// Cache stores forecasts between requests
+// Forecasts is the source of forecast data
+// Geocoder resolves city, state pairs to coordinates
+// Client is the HTTP client shared by the upstream apis
+// Service runs the forecast pipeline over the above components
 type App struct {
-	Router *mux.Router
-	Logger http.Handler
-	DB     *sql.DB
-	Redis  *redis.Client
+	Router    *mux.Router
+	Logger    http.Handler
+	Locations db.LocationStore
+	Cache     cache.ForecastCache
+	Forecasts apis.ForecastProvider
+	Geocoder  apis.Geocoder
+	Client    *apis.Client
+	Service   *service.ForecastService
 }
 
 // InitializeRoutes creates all endpoints for the api
 func (a *App) InitializeRoutes() {
 	a.Router.HandleFunc("/api/forecast/detailed", a.DetailedForecastHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}", "period", "{period:[a-zA-Z+]+}").Methods("GET")
 	a.Router.HandleFunc("/api/forecast/detailed", a.DetailedForecastHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	a.Router.HandleFunc("/api/forecast/detailed", a.DetailedForecastHandler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+	a.Router.HandleFunc("/api/forecast/grid", a.GridForecastHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	a.Router.HandleFunc("/api/forecast/grid", a.GridForecastHandler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+	a.Router.HandleFunc("/api/forecast/week", a.WeekForecastHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	a.Router.HandleFunc("/api/forecast/week", a.WeekForecastHandler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+	a.Router.HandleFunc("/api/location", a.LocationHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	a.Router.HandleFunc("/api/location", a.LocationHandler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+	a.Router.HandleFunc("/api/astro", a.AstroHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	a.Router.HandleFunc("/api/astro", a.AstroHandler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+	a.Router.HandleFunc("/api/discussion", a.DiscussionHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	a.Router.HandleFunc("/api/discussion", a.DiscussionHandler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+	a.Router.HandleFunc("/api/alerts", a.AlertsHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	a.Router.HandleFunc("/api/alerts", a.AlertsHandler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+	a.Router.HandleFunc("/api/observations/latest", a.LatestObservationHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	a.Router.HandleFunc("/api/observations/latest", a.LatestObservationHandler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+	a.Router.HandleFunc("/api/status", a.StatusHandler).Methods("GET")
+	a.Router.HandleFunc("/api/preferences", a.PreferencesHandler).Methods("GET")
+	a.Router.HandleFunc("/api/preferences", a.SetPreferencesHandler).Queries("units", "{units}").Methods("PUT")
 	a.Router.HandleFunc("/api/forecast/detailed/random", a.RandomDetailedForecastHandler).Methods("GET")
 	a.Router.HandleFunc("/api/forecast/hourly", a.HourlyForecastHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}", "hours", "{hours:[0-9]+}").Methods("GET")
 	a.Router.HandleFunc("/api/forecast/hourly", a.HourlyForecastHandler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	a.Router.HandleFunc("/api/forecast/hourly", a.HourlyForecastHandler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+
+	v2 := a.Router.PathPrefix("/api/v2").Subrouter()
+	v2.HandleFunc("/forecast/detailed", a.DetailedForecastV2Handler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	v2.HandleFunc("/forecast/detailed", a.DetailedForecastV2Handler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
+	v2.HandleFunc("/forecast/detailed/random", a.RandomDetailedForecastV2Handler).Methods("GET")
+	v2.HandleFunc("/forecast/hourly", a.HourlyForecastV2Handler).Queries("city", "{city:[a-zA-Z+]+}", "state", "{state:[a-zA-Z+]+}").Methods("GET")
+	v2.HandleFunc("/forecast/hourly", a.HourlyForecastV2Handler).Queries("lat", "{lat}", "lng", "{lng}").Methods("GET")
 	a.Router.NotFoundHandler = http.HandlerFunc(a.Custom404Handler)
 }
 
+// newLocationStore opens the database selected by THORCAST_DB_DRIVER
+// postgres (the default) or sqlite for small deployments
+func newLocationStore(conf config) (db.LocationStore, error) {
+	switch strings.ToLower(conf.sqlDriver) {
+	case "", "postgres":
+		sqlDataSource := fmt.Sprintf(
+			"postgres://%s:%s@%s:%s/%s?sslmode=disable",
+			conf.sqlUsername,
+			conf.sqlPassword,
+			conf.sqlHost,
+			conf.sqlPort,
+			conf.sqlDbName)
+		pg, err := sql.Open("pgx", sqlDataSource)
+		if err != nil {
+			return nil, err
+		}
+		return db.NewPostgresStore(pg), nil
+	case "sqlite":
+		path := conf.sqlitePath
+		if path == "" {
+			path = "thorcast.db"
+		}
+		return db.NewSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", conf.sqlDriver)
+	}
+}
+
+// newCache creates the cache selected by THORCAST_CACHE
+// redis (the default) or memory for single instance deployments
+func newCache(conf config) (cache.ForecastCache, error) {
+	switch strings.ToLower(conf.cacheBackend) {
+	case "", "redis":
+		return cache.NewRedisCache(redis.NewClient(&redis.Options{
+			Addr:     fmt.Sprintf("%s:%s", conf.redisHost, conf.redisPort),
+			Password: conf.redisPassword,
+			DB:       conf.redisDb,
+		})), nil
+	case "memory":
+		return cache.NewMemoryCache(conf.cacheSize), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", conf.cacheBackend)
+	}
+}
+
+// newGeocoder builds the chain of geocoders listed in THORCAST_GEOCODERS
+// defaulting to google alone when none are listed
+func newGeocoder(conf config, client *apis.Client) (apis.Geocoder, error) {
+	var chain apis.GeocoderChain
+	for _, name := range conf.geocoders {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "", "google":
+			chain = append(chain, apis.NewGoogleGeocoder(conf.googleMapsAPI, conf.googleMapsKey, client))
+		case "gazetteer":
+			g, err := apis.NewGazetteerGeocoder(conf.gazetteerFile)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, g)
+		default:
+			return nil, fmt.Errorf("unknown geocoder %q", name)
+		}
+	}
+	return chain, nil
+}
+
 // Initialize creates the application as a whole
 func (a *App) Initialize() {
 	var err error
 	conf.configure()
-	sqlDataSource := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		conf.sqlUsername,
-		conf.sqlPassword,
-		conf.sqlHost,
-		conf.sqlPort,
-		conf.sqlDbName)
-	a.DB, err = sql.Open("pgx", sqlDataSource)
+	a.Locations, err = newLocationStore(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.Cache, err = newCache(conf)
 	if err != nil {
 		log.Fatal(err)
 	}
-	a.Redis = redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", conf.redisHost, conf.redisPort),
-		Password: conf.redisPassword,
-		DB:       conf.redisDb,
+	a.Client = apis.NewClient(apis.ClientOptions{
+		Timeout:   conf.httpTimeout,
+		UserAgent: conf.userAgent,
+		Contact:   conf.contact,
+		Retries:   conf.httpRetries,
+		Breaker:   conf.breaker,
 	})
+	a.Forecasts = apis.NewWeatherGov(conf.weatherGovAPI, a.Client)
+	a.Geocoder, err = newGeocoder(conf, a.Client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.Service = service.NewForecastService(a.Cache, a.Locations, a.Geocoder, a.Forecasts)
 	a.Router = mux.NewRouter()
 	a.Logger = handlers.CombinedLoggingHandler(os.Stdout, a.Router)
 	a.InitializeRoutes()
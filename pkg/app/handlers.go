@@ -0,0 +1,387 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/responses"
+	"github.com/kylep342/thorcast-server/pkg/service"
+	"github.com/kylep342/thorcast-server/pkg/units"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+// Custom404Handler defines a catchall response for invalid API endpoints
+func (a *App) Custom404Handler(w http.ResponseWriter, r *http.Request) {
+	responses.RespondWithError(w, r, errs.ErrNotFound)
+}
+
+// Header identifying the user of a frontend, whose preferences apply to
+// their requests
+const userHeader = "X-Thorcast-User"
+
+// units returns the System of units to respond to a request in: its units
+// parameter, else the System its user prefers, else US units
+func (a *App) units(r *http.Request) (units.System, error) {
+	return a.Service.Units(r.Header.Get(userHeader), r.URL.Query().Get("units"))
+}
+
+// hasCoordinates reports whether a request is for a latitude and longitude
+// rather than a city and state
+// Every handler taking a city and state also takes lat and lng instead,
+// for places without a city name; those are never geocoded or stored, and
+// responses name them by the nearest city weather.gov reports, e.g.
+// "near Ely, MN" (see withLocation for the v1 text responses)
+func hasCoordinates(params url.Values) bool {
+	return params.Get("lat") != "" || params.Get("lng") != ""
+}
+
+// withLocation adds the location of a v1 response to it: the city and
+// state requested, or for a request by coordinates, their label (e.g.
+// "near Ely, MN") and the coordinates themselves, as they are not in the
+// nearest city weather.gov reports
+func withLocation(resp map[string]string, city utils.City, state utils.State, l models.Location) map[string]string {
+	if l.City != "" {
+		resp["city"] = city.Name()
+		resp["state"] = state.Name()
+		return resp
+	}
+	resp["location"] = l.Name()
+	resp["lat"] = strconv.FormatFloat(l.Lat, 'f', -1, 64)
+	resp["lng"] = strconv.FormatFloat(l.Lng, 'f', -1, 64)
+	return resp
+}
+
+// respondWithServiceError logs and responds with an error from the ForecastService
+func respondWithServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("Error serving forecast: %s\n", err.Error())
+	responses.RespondWithError(w, r, err)
+}
+
+// formatHourlyForecast renders an hourly forecast period as a line of text
+func formatHourlyForecast(fc cache.CachedPeriod) string {
+	fcDate, _ := time.Parse(time.RFC3339, fc.StartTime)
+	return fmt.Sprintf(
+		"%s Forecast: %s, Temperature: %d\u00B0 %s, Wind: %s %s",
+		fcDate.Format(time.RFC3339),
+		fc.ShortForecast,
+		int(fc.Temperature),
+		fc.TemperatureUnit,
+		fc.WindSpeed,
+		fc.WindDirection)
+}
+
+// GridForecastHandler returns hourly time series of the raw forecast data
+// for the specified city and state
+func (a *App) GridForecastHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	var result service.GridForecast
+	if hasCoordinates(params) {
+		result, err = a.Service.GridAt(r.Context(), params.Get("lat"), params.Get("lng"))
+	} else {
+		result, err = a.Service.Grid(r.Context(), params.Get("city"), params.Get("state"))
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	resp, err := responses.NewGridForecast(result.Location, result.Grid, system)
+	if err != nil {
+		respondWithServiceError(w, r, errs.Wrap(errs.ErrUpstream, err))
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// WeekForecastHandler returns every period of the detailed forecast for
+// the specified city and state, in order
+func (a *App) WeekForecastHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	var result service.WeekForecast
+	if hasCoordinates(params) {
+		result, err = a.Service.WeekAt(r.Context(), params.Get("lat"), params.Get("lng"), system)
+	} else {
+		result, err = a.Service.Week(r.Context(), params.Get("city"), params.Get("state"), system)
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	resp := responses.WeekForecast{
+		Location: responses.NewLocation(result.Location),
+		Periods:  make([]responses.Period, 0, len(result.Forecasts)),
+		Stale:    result.Stale,
+	}
+	for _, fc := range result.Forecasts {
+		resp.Periods = append(resp.Periods, responses.NewPeriod(fc.ForecastPeriod))
+		resp.GeneratedAt = fc.GeneratedAt
+	}
+	responses.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// LocationHandler returns the stored location of the specified city and
+// state along with the weather.gov metadata of its grid point
+func (a *App) LocationHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	var result service.LocationMetadata
+	if hasCoordinates(params) {
+		result, err = a.Service.LocationAt(r.Context(), params.Get("lat"), params.Get("lng"))
+	} else {
+		result, err = a.Service.Location(r.Context(), params.Get("city"), params.Get("state"))
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, responses.NewLocationMetadata(result.Location, result.Points, system))
+}
+
+// AstroHandler returns the times of sunrise, sunset, solar noon, twilight,
+// moonrise and moonset and the phase of the Moon at the specified city
+// and state
+// if date (YYYY-MM-DD) is not specified in the HTTP request, it defaults
+// to today in the location's time zone
+func (a *App) AstroHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	var result service.Astronomy
+	var err error
+	if hasCoordinates(params) {
+		result, err = a.Service.AstroAt(r.Context(), params.Get("lat"), params.Get("lng"), params.Get("date"))
+	} else {
+		result, err = a.Service.Astro(r.Context(), params.Get("city"), params.Get("state"), params.Get("date"))
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, responses.NewAstro(result.Location, result.Date, result.Sun, result.Moon))
+}
+
+// DiscussionHandler returns the latest Area Forecast Discussion of the
+// forecast office covering the specified city and state, split into its
+// sections
+func (a *App) DiscussionHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	var result service.AreaDiscussion
+	var err error
+	if hasCoordinates(params) {
+		result, err = a.Service.DiscussionAt(r.Context(), params.Get("lat"), params.Get("lng"))
+	} else {
+		result, err = a.Service.Discussion(r.Context(), params.Get("city"), params.Get("state"))
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, responses.NewDiscussion(result.Location, result.Office, result.Discussion, result.Sections))
+}
+
+// AlertsHandler returns the weather alerts in effect for the specified
+// city and state, or latitude and longitude
+func (a *App) AlertsHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	var result service.LocationAlerts
+	var err error
+	if hasCoordinates(params) {
+		result, err = a.Service.ActiveAlertsAt(r.Context(), params.Get("lat"), params.Get("lng"))
+	} else {
+		result, err = a.Service.ActiveAlerts(r.Context(), params.Get("city"), params.Get("state"))
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, responses.NewAlerts(result.Location, result.Alerts))
+}
+
+// LatestObservationHandler returns the current conditions observed at the
+// nearest station to the specified city and state
+// units=si reports them in metric units rather than US units
+func (a *App) LatestObservationHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	var result service.CurrentConditions
+	if hasCoordinates(params) {
+		result, err = a.Service.LatestObservationAt(r.Context(), params.Get("lat"), params.Get("lng"))
+	} else {
+		result, err = a.Service.LatestObservation(r.Context(), params.Get("city"), params.Get("state"))
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, responses.NewObservation(result.Location, result.Observation, system))
+}
+
+// StatusHandler reports the state of the circuit breaker of each upstream api
+func (a *App) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	var upstreams map[string]apis.BreakerStatus
+	if a.Client != nil {
+		upstreams = a.Client.BreakerStatus()
+	}
+	responses.RespondWithJSON(w, http.StatusOK, responses.NewStatus(upstreams))
+}
+
+// Formats of a detailed forecast response
+// formatText is the forecast's text alone, formatFull every field of it
+const (
+	formatText = "text"
+	formatFull = "full"
+)
+
+// parseFormat validates the format parameter of a request, defaulting to text
+func parseFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", formatText:
+		return formatText, nil
+	case formatFull:
+		return formatFull, nil
+	default:
+		return "", errs.WithDetail(
+			errs.ErrInvalidFormat,
+			fmt.Sprintf("%q is not one of text or full.", format))
+	}
+}
+
+// respondWithDetailedForecast responds with a detailed forecast in the given format
+func respondWithDetailedForecast(w http.ResponseWriter, result service.DetailedForecast, format string) {
+	if format == formatFull {
+		responses.RespondWithJSON(w, http.StatusOK, newDetailedForecastResponse(result))
+		return
+	}
+	resp := map[string]string{
+		"forecast": result.Forecast.DetailedForecast,
+		"period":   result.Period.Name()}
+	responses.RespondWithJSON(w, http.StatusOK, withLocation(resp, result.City, result.State, result.Location))
+}
+
+// HourlyForecastHandler returns hourly forecast data for the specified city, state, and duration
+// if hours is not specified in the HTTP request, it defaults to 12
+func (a *App) HourlyForecastHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	var result service.HourlyForecast
+	if hasCoordinates(params) {
+		result, err = a.Service.HourlyAt(r.Context(), params.Get("lat"), params.Get("lng"), params.Get("hours"), system)
+	} else {
+		result, err = a.Service.Hourly(r.Context(), params.Get("city"), params.Get("state"), params.Get("hours"), system)
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	hourlyForecasts := make([]string, 0, len(result.Forecasts))
+	for _, fc := range result.Forecasts {
+		hourlyForecasts = append(hourlyForecasts, formatHourlyForecast(fc))
+	}
+	resp := map[string]string{
+		"forecast": strings.Join(hourlyForecasts, "\n"),
+		"hours":    strconv.FormatInt(result.Hours, 10)}
+	responses.RespondWithJSON(w, http.StatusOK, withLocation(resp, result.City, result.State, result.Location))
+}
+
+// DetailedForecastHandler returns the detailed forecast for a given city, state, and period
+// if period is not specified in the HTTP request, it defaults to today
+// format=full returns every field of the forecast period rather than its text
+func (a *App) DetailedForecastHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	format, err := parseFormat(params.Get("format"))
+	if err != nil {
+		responses.RespondWithError(w, r, err)
+		return
+	}
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	var result service.DetailedForecast
+	if hasCoordinates(params) {
+		result, err = a.Service.DetailedAt(r.Context(), params.Get("lat"), params.Get("lng"), params.Get("period"), system)
+	} else {
+		result, err = a.Service.Detailed(r.Context(), params.Get("city"), params.Get("state"), params.Get("period"), system)
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	respondWithDetailedForecast(w, result, format)
+}
+
+// RandomDetailedForecastHandler provides a forecast for a random city, state, and period
+// city and state are determined by selecting a random location from the database
+// period is selected randomly within the next week
+// format=full returns every field of the forecast period rather than its text
+func (a *App) RandomDetailedForecastHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := parseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		responses.RespondWithError(w, r, err)
+		return
+	}
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	result, err := a.Service.RandomDetailed(r.Context(), system)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	respondWithDetailedForecast(w, result, format)
+}
+
+// PreferencesHandler returns the preferences of the user identified by the
+// X-Thorcast-User header
+func (a *App) PreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := strings.TrimSpace(r.Header.Get(userHeader))
+	system, err := a.Service.PreferredUnits(user)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, responses.Preferences{User: user, Units: string(system)})
+}
+
+// SetPreferencesHandler stores the system of units the user identified by
+// the X-Thorcast-User header prefers, the default of their requests
+func (a *App) SetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := strings.TrimSpace(r.Header.Get(userHeader))
+	system, err := a.Service.SetUnits(user, r.URL.Query().Get("units"))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, responses.Preferences{User: user, Units: string(system)})
+}
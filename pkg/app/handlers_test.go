@@ -0,0 +1,402 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/db"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/responses"
+	"github.com/kylep342/thorcast-server/pkg/service"
+)
+
+const testGazetteer = `city,state,lat,lng
+Chicago,IL,41.8781,-87.6298
+`
+
+// newTestApp builds an App backed by in-memory components, with a
+// forecast for today in Chicago
+func newTestApp(t *testing.T) *App {
+	store, err := db.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sqlite: %s", err.Error())
+	}
+	geocoder, _ := apis.ReadGazetteer(strings.NewReader(testGazetteer))
+	provider := apis.NewFakeForecastProvider()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var detailed, hourly apis.Forecasts
+	detailed.Properties.Periods = []apis.ForecastPeriod{{
+		Name:             "Today",
+		StartTime:        today.Format(time.RFC3339),
+		EndTime:          today.Add(18 * time.Hour).Format(time.RFC3339),
+		IsDaytime:        true,
+		Temperature:      75,
+		TemperatureUnit:  "F",
+		WindSpeed:        "5 mph",
+		WindDirection:    "SW",
+		ShortForecast:    "Sunny",
+		DetailedForecast: "Sunny, with a high near 75."}}
+	hourly.Properties.Periods = []apis.ForecastPeriod{{
+		StartTime:       today.Format(time.RFC3339),
+		Temperature:     70,
+		TemperatureUnit: "F",
+		WindSpeed:       "5 mph",
+		WindDirection:   "SW",
+		ShortForecast:   "Sunny"}}
+	points := provider.AddLocation(models.Coordinates{Lat: 41.8781, Lng: -87.6298}, detailed, hourly)
+	points.Properties.TimeZone = "America/Chicago"
+	provider.Points[models.Coordinates{Lat: 41.8781, Lng: -87.6298}] = points
+
+	var grid apis.GridData
+	temperature := 21.5
+	grid.Properties.Temperature = apis.GridLayer{
+		Uom:    "wmoUnit:degC",
+		Values: []apis.GridValue{{ValidTime: today.Format(time.RFC3339) + "/PT2H", Value: &temperature}}}
+	provider.GridData[points.Properties.ForecastGridData] = grid
+
+	provider.Alerts[models.Coordinates{Lat: 41.8781, Lng: -87.6298}] = apis.Alerts{Features: []apis.AlertFeature{{
+		Properties: apis.Alert{Event: "Heat Advisory", Severity: "Moderate", AffectedZones: []string{"ILZ014"}}}}}
+
+	a := &App{
+		Router:    mux.NewRouter(),
+		Cache:     cache.NewMemoryCache(0),
+		Locations: store,
+		Geocoder:  geocoder,
+		Forecasts: provider,
+	}
+	a.Service = service.NewForecastService(a.Cache, a.Locations, a.Geocoder, a.Forecasts)
+	a.InitializeRoutes()
+	return a
+}
+
+func serve(a *App, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	return w
+}
+
+func TestDetailedForecastHandler(t *testing.T) {
+	a := newTestApp(t)
+
+	w := serve(a, "/api/forecast/detailed?city=Chicago&state=IL")
+
+	var body map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusOK || body["forecast"] != "Sunny, with a high near 75." || body["city"] != "Chicago" {
+		t.Errorf("Response was incorrect, got: %d %v", w.Code, body)
+	}
+}
+
+func TestDetailedForecastHandlerFullFormat(t *testing.T) {
+	a := newTestApp(t)
+
+	// the second request is served from the cache
+	for i := 0; i < 2; i++ {
+		w := serve(a, "/api/forecast/detailed?city=Chicago&state=IL&format=full")
+
+		var body responses.DetailedForecast
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+		if w.Code != http.StatusOK || body.Period.Temperature.Value != 75 || body.Period.ShortForecast != "Sunny" || body.Period.DetailedForecast == "" {
+			t.Errorf("Response was incorrect, got: %d %v", w.Code, body)
+		}
+	}
+
+	w := serve(a, "/api/forecast/detailed?city=Chicago&state=IL&format=haiku")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status was incorrect, got: %d, want: %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestDetailedForecastV2Handler(t *testing.T) {
+	a := newTestApp(t)
+
+	w := serve(a, "/api/v2/forecast/detailed?city=Chicago&state=IL")
+
+	var body responses.DetailedForecast
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status was incorrect, got: %d, want: %d", w.Code, http.StatusOK)
+	}
+	if body.Location.Name != "Chicago, IL" || body.Location.Lat != 41.8781 {
+		t.Errorf("Location was incorrect, got: %v", body.Location)
+	}
+	if body.Period.Temperature.Value != 75 || body.Period.Wind.Direction != "SW" || body.Period.StartTime.IsZero() {
+		t.Errorf("Period was incorrect, got: %v", body.Period)
+	}
+	if body.Sun == nil || body.Sun.Sunset == nil {
+		t.Errorf("Sun was incorrect, got: %v", body.Sun)
+	}
+	if body.Moon != nil {
+		t.Errorf("Moon was incorrect for a daytime period, got: %v, want: nil", body.Moon)
+	}
+}
+
+func TestForecastHandlersAtCoordinates(t *testing.T) {
+	a := newTestApp(t)
+	provider := a.Forecasts.(*apis.FakeForecastProvider)
+	c := models.Coordinates{Lat: 41.8781, Lng: -87.6298}
+	points := provider.Points[c]
+	points.Properties.RelativeLocation.Properties.City = "Chicago"
+	points.Properties.RelativeLocation.Properties.State = "IL"
+	provider.Points[c] = points
+
+	w := serve(a, "/api/v2/forecast/detailed?lat=41.87811&lng=-87.62979")
+
+	var body responses.DetailedForecast
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusOK || body.Period.Temperature.Value != 75 {
+		t.Fatalf("Response was incorrect, got: %d %+v", w.Code, body)
+	}
+	if body.Location.Name != "near Chicago, IL" || body.Location.City != "" || body.Location.Lat != 41.8781 {
+		t.Errorf("Location was incorrect, got: %+v", body.Location)
+	}
+
+	for _, target := range []string{
+		"/api/forecast/detailed?lat=41.8781&lng=-87.6298&period=today",
+		"/api/forecast/hourly?lat=41.8781&lng=-87.6298&hours=1",
+		"/api/forecast/week?lat=41.8781&lng=-87.6298",
+		"/api/forecast/grid?lat=41.8781&lng=-87.6298",
+		"/api/v2/forecast/hourly?lat=41.8781&lng=-87.6298",
+	} {
+		if w := serve(a, target); w.Code != http.StatusOK {
+			t.Errorf("Status of %s was incorrect, got: %d, want: %d", target, w.Code, http.StatusOK)
+		}
+	}
+
+	// v1 responses name the coordinates rather than the nearest city
+	for _, target := range []string{
+		"/api/forecast/detailed?lat=41.8781&lng=-87.6298",
+		"/api/forecast/hourly?lat=41.8781&lng=-87.6298",
+	} {
+		var v1 map[string]string
+		_ = json.Unmarshal(serve(a, target).Body.Bytes(), &v1)
+		if _, ok := v1["city"]; ok || v1["location"] != "near Chicago, IL" || v1["lat"] != "41.8781" || v1["lng"] != "-87.6298" {
+			t.Errorf("Location of %s was incorrect, got: %v", target, v1)
+		}
+	}
+
+	if w := serve(a, "/api/forecast/hourly?lat=41.8781&lng=-187"); w.Code != http.StatusBadRequest {
+		t.Errorf("Status was incorrect, got: %d, want: %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestLocationHandlersAtCoordinates(t *testing.T) {
+	a := newTestApp(t)
+	provider := a.Forecasts.(*apis.FakeForecastProvider)
+	c := models.Coordinates{Lat: 41.8781, Lng: -87.6298}
+	points := provider.Points[c]
+	points.Properties.RelativeLocation.Properties.City = "Chicago"
+	points.Properties.RelativeLocation.Properties.State = "IL"
+	provider.Points[c] = points
+	provider.Discussions["TST"] = apis.Product{ID: "afd-1", ProductText: ".SYNOPSIS...\nShowers.\n&&"}
+	var station apis.Station
+	station.Properties.StationIdentifier = "KMDW"
+	station.Geometry.Coordinates = []float64{-87.7551, 41.7841}
+	provider.Stations[points.Properties.ObservationStations] = apis.Stations{Features: []apis.Station{station}}
+	var observation apis.Observation
+	observation.Properties.TextDescription = "Clear"
+	provider.Observations["KMDW"] = observation
+
+	for _, target := range []string{
+		"/api/location?lat=41.8781&lng=-87.6298",
+		"/api/astro?lat=41.8781&lng=-87.6298&date=2020-06-20",
+		"/api/discussion?lat=41.8781&lng=-87.6298",
+		"/api/observations/latest?lat=41.8781&lng=-87.6298",
+	} {
+		w := serve(a, target)
+
+		var body struct {
+			Location responses.Location `json:"location"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+		if w.Code != http.StatusOK || body.Location.Name != "near Chicago, IL" || body.Location.City != "" {
+			t.Errorf("Response to %s was incorrect, got: %d %+v", target, w.Code, body.Location)
+		}
+	}
+
+	if _, err := a.Locations.Lookup("Chicago", "IL"); err == nil {
+		t.Error("Location was stored for a request by coordinates")
+	}
+}
+
+func TestHourlyForecastHandlerUnknownCity(t *testing.T) {
+	a := newTestApp(t)
+
+	w := serve(a, "/api/forecast/hourly?city=Gotham&state=NY")
+
+	var body map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusNotFound || body["code"] != "location_not_found" {
+		t.Errorf("Response was incorrect, got: %d %v", w.Code, body)
+	}
+}
+
+func TestGridForecastHandler(t *testing.T) {
+	a := newTestApp(t)
+
+	w := serve(a, "/api/forecast/grid?city=Chicago&state=IL&units=si")
+
+	var body responses.GridForecast
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status was incorrect, got: %d, want: %d", w.Code, http.StatusOK)
+	}
+	if body.Temperature.Unit != "°C" || len(body.Temperature.Values) != 2 || *body.Temperature.Values[1].Value != 21.5 {
+		t.Errorf("Temperature was incorrect, got: %v", body.Temperature)
+	}
+}
+
+func TestPreferredUnits(t *testing.T) {
+	a := newTestApp(t)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/api/preferences?units=si", nil)
+	req.Header.Set("X-Thorcast-User", "U123")
+	a.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status was incorrect, got: %d, want: %d", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/v2/forecast/hourly?city=Chicago&state=IL", nil)
+	req.Header.Set("X-Thorcast-User", "U123")
+	a.Router.ServeHTTP(w, req)
+
+	var body responses.HourlyForecast
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusOK || len(body.Periods) != 1 || body.Periods[0].Temperature.Unit != "C" {
+		t.Errorf("Response was incorrect, got: %d %+v", w.Code, body)
+	}
+	if body.Hours != 12 {
+		t.Errorf("Hours were incorrect, got: %d, want: the 12 requested by default", body.Hours)
+	}
+
+	if w := serve(a, "/api/forecast/hourly?city=Chicago&state=IL&units=kelvin"); w.Code != http.StatusBadRequest {
+		t.Errorf("Status was incorrect, got: %d, want: %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestLocationHandler(t *testing.T) {
+	a := newTestApp(t)
+	provider := a.Forecasts.(*apis.FakeForecastProvider)
+	c := models.Coordinates{Lat: 41.8781, Lng: -87.6298}
+	points := provider.Points[c]
+	points.Properties.FireWeatherZone = "https://api.weather.gov/zones/fire/ILZ014"
+	points.Properties.RelativeLocation.Properties.City = "Chicago"
+	points.Properties.RelativeLocation.Properties.State = "IL"
+	points.Properties.RelativeLocation.Properties.Distance.UnitCode = "wmoUnit:m"
+	points.Properties.RelativeLocation.Properties.Distance.Value = 1609.344
+	provider.Points[c] = points
+
+	w := serve(a, "/api/location?city=Chicago&state=IL")
+
+	var body responses.LocationMetadata
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusOK || body.Office != "TST" || body.FireWeatherZone != "ILZ014" || body.Location.Lat != 41.8781 {
+		t.Errorf("Response was incorrect, got: %d %+v", w.Code, body)
+	}
+	distance := body.RelativeLocation.Distance
+	if distance.Value == nil || *distance.Value != 1 || distance.Unit != "mi" {
+		t.Errorf("Distance was incorrect, got: %+v, want: 1 mi", distance)
+	}
+}
+
+func TestAstroHandler(t *testing.T) {
+	a := newTestApp(t)
+
+	w := serve(a, "/api/astro?city=Chicago&state=IL&date=2020-06-20")
+
+	var body responses.Astro
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusOK || body.Date != "2020-06-20" || body.Location.Name != "Chicago, IL" || body.Sun == nil {
+		t.Fatalf("Response was incorrect, got: %d %+v", w.Code, body)
+	}
+	// the time zone of the grid point is used though no forecast was fetched
+	if body.TimeZone != "America/Chicago" {
+		t.Errorf("Time zone was incorrect, got: %s, want: America/Chicago", body.TimeZone)
+	}
+	if body.Sun.Sunset == nil || body.Sun.Sunset.Format("2006-01-02") != "2020-06-20" {
+		t.Errorf("Sunset was incorrect, got: %v", body.Sun.Sunset)
+	}
+	if day := body.Sun.DayLength; day.Value == nil || *day.Value < 900 || *day.Value > 920 || day.Unit != "min" {
+		t.Errorf("Day length was incorrect, got: %+v, want: about 914 min", day)
+	}
+	if body.Moon == nil || body.Moon.Phase != "New Moon" || body.Moon.Illumination.Value == nil || *body.Moon.Illumination.Value > 5 {
+		t.Errorf("Moon was incorrect, got: %+v, want: a new moon", body.Moon)
+	}
+
+	if w := serve(a, "/api/astro?city=Chicago&state=IL&date=tomorrow"); w.Code != http.StatusBadRequest {
+		t.Errorf("Status was incorrect, got: %d, want: %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestDiscussionHandler(t *testing.T) {
+	a := newTestApp(t)
+	a.Forecasts.(*apis.FakeForecastProvider).Discussions["TST"] = apis.Product{
+		ID:          "afd-1",
+		ProductText: "AFDTST\n\n.SHORT TERM /TODAY THROUGH SUNDAY/...\nWarm.\n&&\n$$"}
+
+	w := serve(a, "/api/discussion?city=Chicago&state=IL")
+
+	var body responses.Discussion
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusOK || body.Office != "TST" || len(body.Sections) != 1 {
+		t.Fatalf("Response was incorrect, got: %d %+v", w.Code, body)
+	}
+	if s := body.Sections[0]; s.Key != "short_term" || s.Period != "TODAY THROUGH SUNDAY" || s.Text != "Warm." {
+		t.Errorf("Section was incorrect, got: %+v", s)
+	}
+}
+
+func TestWeekForecastHandler(t *testing.T) {
+	a := newTestApp(t)
+
+	w := serve(a, "/api/forecast/week?city=Chicago&state=IL")
+
+	var body responses.WeekForecast
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusOK || len(body.Periods) != 1 || body.Periods[0].Name != "Today" || body.Location.Name != "Chicago, IL" {
+		t.Errorf("Response was incorrect, got: %d %+v", w.Code, body)
+	}
+}
+
+func TestAlertsHandler(t *testing.T) {
+	a := newTestApp(t)
+
+	for _, target := range []string{"/api/alerts?city=Chicago&state=IL", "/api/alerts?lat=41.87812&lng=-87.62981"} {
+		w := serve(a, target)
+
+		var body responses.Alerts
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+		if w.Code != http.StatusOK || len(body.Alerts) != 1 || body.Alerts[0].Event != "Heat Advisory" {
+			t.Errorf("Response to %s was incorrect, got: %d %v", target, w.Code, body)
+		}
+	}
+
+	w := serve(a, "/api/alerts?lat=north&lng=-87.6298")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status was incorrect, got: %d, want: %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/kylep342/thorcast-server/pkg/responses"
+	"github.com/kylep342/thorcast-server/pkg/service"
+)
+
+// newDetailedForecastResponse creates the v2 response body for a detailed forecast
+func newDetailedForecastResponse(result service.DetailedForecast) responses.DetailedForecast {
+	return responses.DetailedForecast{
+		Location:    responses.NewLocation(result.Location),
+		Period:      responses.NewPeriod(result.Forecast.ForecastPeriod),
+		GeneratedAt: result.Forecast.GeneratedAt,
+		Stale:       result.Stale,
+		Sun:         responses.NewSun(result.Sun),
+		Moon:        responses.NewMoon(result.Moon),
+	}
+}
+
+// DetailedForecastV2Handler returns the detailed forecast for a given city, state, and period
+// if period is not specified in the HTTP request, it defaults to today
+func (a *App) DetailedForecastV2Handler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	var result service.DetailedForecast
+	if hasCoordinates(params) {
+		result, err = a.Service.DetailedAt(r.Context(), params.Get("lat"), params.Get("lng"), params.Get("period"), system)
+	} else {
+		result, err = a.Service.Detailed(r.Context(), params.Get("city"), params.Get("state"), params.Get("period"), system)
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, newDetailedForecastResponse(result))
+}
+
+// RandomDetailedForecastV2Handler provides a forecast for a random city, state, and period
+func (a *App) RandomDetailedForecastV2Handler(w http.ResponseWriter, r *http.Request) {
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	result, err := a.Service.RandomDetailed(r.Context(), system)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	responses.RespondWithJSON(w, http.StatusOK, newDetailedForecastResponse(result))
+}
+
+// HourlyForecastV2Handler returns hourly forecast data for the specified city, state, and duration
+// if hours is not specified in the HTTP request, it defaults to 12
+func (a *App) HourlyForecastV2Handler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	system, err := a.units(r)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	var result service.HourlyForecast
+	if hasCoordinates(params) {
+		result, err = a.Service.HourlyAt(r.Context(), params.Get("lat"), params.Get("lng"), params.Get("hours"), system)
+	} else {
+		result, err = a.Service.Hourly(r.Context(), params.Get("city"), params.Get("state"), params.Get("hours"), system)
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+	resp := responses.HourlyForecast{
+		Location: responses.NewLocation(result.Location),
+		Hours:    int(result.Hours),
+		Periods:  make([]responses.Period, 0, len(result.Forecasts)),
+		Stale:    result.Stale,
+	}
+	for _, fc := range result.Forecasts {
+		resp.Periods = append(resp.Periods, responses.NewPeriod(fc.ForecastPeriod))
+		resp.GeneratedAt = fc.GeneratedAt
+	}
+	responses.RespondWithJSON(w, http.StatusOK, resp)
+}
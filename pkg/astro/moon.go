@@ -0,0 +1,172 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Altitude in degrees of the center of the Moon at moonrise and moonset,
+// allowing for atmospheric refraction, the radius of the Moon and its
+// parallax
+const moonriseAltitude = 0.125
+
+// Mean distance from the Earth to the Sun in kilometers
+const sunDistance = 149598000.0
+
+// Interval at which the altitude of the Moon is sampled to find its
+// rising and setting
+const moonStep = 10 * time.Minute
+
+// Names of the phases of the Moon, each covering an eighth of the lunar
+// month centered on its phase
+var phaseNames = []string{
+	"New Moon",
+	"Waxing Crescent",
+	"First Quarter",
+	"Waxing Gibbous",
+	"Full Moon",
+	"Waning Gibbous",
+	"Last Quarter",
+	"Waning Crescent",
+}
+
+// MoonPhase is the phase of the Moon at an instant
+// Phase is the fraction of the lunar month elapsed since the new moon,
+// from 0 to 1 (0.5 is the full moon), and Illumination the fraction of
+// its disk lit by the Sun, from 0 to 1
+type MoonPhase struct {
+	Name         string
+	Phase        float64
+	Illumination float64
+}
+
+// MoonTimes are the times of the lunar events of a day (or another span of
+// time) at a location
+// An event is the zero time when it does not happen in that span, as the
+// Moon rises about 50 minutes later each day, and sometimes neither
+// rises nor sets at high latitudes
+// AlwaysUp and AlwaysDown report whether the Moon stays above or below
+// the horizon throughout
+// Phase is the phase of the Moon at noon, or the middle of the span
+type MoonTimes struct {
+	Moonrise   time.Time
+	Moonset    time.Time
+	AlwaysUp   bool
+	AlwaysDown bool
+	Phase      MoonPhase
+}
+
+// Moon computes the MoonTimes of the calendar day of date at the given
+// coordinates, in the time zone of date
+// Its low precision lunar theory is accurate to a few minutes
+func Moon(date time.Time, lat, lng float64) MoonTimes {
+	y, m, d := date.Date()
+	loc := date.Location()
+	start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+
+	times := MoonBetween(start, end, lat, lng)
+	times.Phase = Phase(time.Date(y, m, d, 12, 0, 0, 0, loc))
+	return times
+}
+
+// MoonBetween computes the MoonTimes of the span of time [start, end) at
+// the given coordinates, e.g. of a night, which spans two calendar days
+// Times are in the time zone of start
+func MoonBetween(start, end time.Time, lat, lng float64) MoonTimes {
+	times := MoonTimes{Phase: Phase(start.Add(end.Sub(start) / 2))}
+	prev := moonAltitude(start, lat, lng) - moonriseAltitude
+	above := prev >= 0
+	for t := start; t.Before(end); {
+		next := t.Add(moonStep)
+		if next.After(end) {
+			next = end
+		}
+		alt := moonAltitude(next, lat, lng) - moonriseAltitude
+		if prev < 0 && alt >= 0 && times.Moonrise.IsZero() {
+			times.Moonrise = crossing(t, next, prev, alt)
+		} else if prev >= 0 && alt < 0 && times.Moonset.IsZero() {
+			times.Moonset = crossing(t, next, prev, alt)
+		}
+		t, prev = next, alt
+	}
+	if times.Moonrise.IsZero() && times.Moonset.IsZero() {
+		times.AlwaysUp = above
+		times.AlwaysDown = !above
+	}
+	return times
+}
+
+// crossing interpolates linearly the time between t0 and t1 at which an
+// altitude going from alt0 to alt1 crosses the horizon
+func crossing(t0, t1 time.Time, alt0, alt1 float64) time.Time {
+	return t0.Add(time.Duration(float64(t1.Sub(t0)) * alt0 / (alt0 - alt1))).Round(time.Second)
+}
+
+// Phase computes the MoonPhase at t
+func Phase(t time.Time) MoonPhase {
+	days := daysSinceJ2000(t)
+	sunRA, sunDec := sunCoordinates(days)
+	moonRA, moonDec, moonDistance := moonCoordinates(days)
+	// angular distance between the Sun and the Moon, and the Moon's phase
+	// angle as seen from the Sun
+	elongation := deg(math.Acos(sin(sunDec)*sin(moonDec) + cos(sunDec)*cos(moonDec)*cos(sunRA-moonRA)))
+	incidence := deg(math.Atan2(sunDistance*sin(elongation), moonDistance-sunDistance*cos(elongation)))
+	angle := math.Atan2(
+		cos(sunDec)*sin(sunRA-moonRA),
+		sin(sunDec)*cos(moonDec)-cos(sunDec)*sin(moonDec)*cos(sunRA-moonRA))
+	phase := 0.5 + 0.5*incidence/180
+	if angle < 0 {
+		phase = 0.5 - 0.5*incidence/180
+	}
+	return MoonPhase{
+		Name:         phaseNames[int(math.Floor(phase*8+0.5))%len(phaseNames)],
+		Phase:        phase,
+		Illumination: (1 + cos(incidence)) / 2,
+	}
+}
+
+// moonAltitude returns the geometric altitude of the center of the Moon
+// in degrees at t and the given coordinates
+func moonAltitude(t time.Time, lat, lng float64) float64 {
+	days := daysSinceJ2000(t)
+	ra, dec, _ := moonCoordinates(days)
+	siderealTime := 280.16 + 360.9856235*days + lng
+	hourAngle := siderealTime - ra
+	return deg(math.Asin(sin(lat)*sin(dec) + cos(lat)*cos(dec)*cos(hourAngle)))
+}
+
+// moonCoordinates returns the geocentric right ascension and declination
+// of the Moon in degrees and its distance in kilometers, a number of days
+// after J2000.0
+func moonCoordinates(days float64) (float64, float64, float64) {
+	meanLongitude := 218.316 + 13.176396*days
+	meanAnomaly := 134.963 + 13.064993*days
+	meanDistance := 93.272 + 13.229350*days
+	longitude := meanLongitude + 6.289*sin(meanAnomaly)
+	latitude := 5.128 * sin(meanDistance)
+	distance := 385001 - 20905*cos(meanAnomaly)
+	ra, dec := equatorial(longitude, latitude)
+	return ra, dec, distance
+}
+
+// sunCoordinates returns the geocentric right ascension and declination
+// of the Sun in degrees, a number of days after J2000.0
+func sunCoordinates(days float64) (float64, float64) {
+	m := normalize(357.5291 + 0.98560028*days)
+	center := 1.9148*sin(m) + 0.0200*sin(2*m) + 0.0003*sin(3*m)
+	return equatorial(m+center+180+102.9372, 0)
+}
+
+// equatorial converts ecliptic longitude and latitude in degrees to right
+// ascension and declination in degrees
+func equatorial(longitude, latitude float64) (float64, float64) {
+	ra := deg(math.Atan2(sin(longitude)*cos(obliquity)-math.Tan(rad(latitude))*sin(obliquity), cos(longitude)))
+	dec := deg(math.Asin(sin(latitude)*cos(obliquity) + cos(latitude)*sin(obliquity)*sin(longitude)))
+	return ra, dec
+}
+
+// daysSinceJ2000 returns the number of days from J2000.0 to t
+func daysSinceJ2000(t time.Time) float64 {
+	return t.Sub(j2000Epoch).Hours() / 24
+}
@@ -0,0 +1,69 @@
+package astro
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMoon(t *testing.T) {
+	// times and phase published by the suncalc library
+	moon := Moon(time.Date(2013, 3, 4, 0, 0, 0, 0, time.UTC), 50.5, 30.5)
+	cases := []struct {
+		name      string
+		got, want time.Time
+	}{
+		{"moonrise", moon.Moonrise, time.Date(2013, 3, 4, 23, 54, 29, 0, time.UTC)},
+		{"moonset", moon.Moonset, time.Date(2013, 3, 4, 7, 47, 58, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if d := c.got.Sub(c.want); d < -5*time.Minute || d > 5*time.Minute {
+			t.Errorf("%s was incorrect, got: %s, want: %s", c.name, c.got, c.want)
+		}
+	}
+	if moon.AlwaysUp || moon.AlwaysDown {
+		t.Errorf("Moon was incorrectly always up (%v) or down (%v)", moon.AlwaysUp, moon.AlwaysDown)
+	}
+
+	phase := Phase(time.Date(2013, 3, 5, 0, 0, 0, 0, time.UTC))
+	if math.Abs(phase.Illumination-0.4848) > 0.001 || math.Abs(phase.Phase-0.7548) > 0.001 || phase.Name != "Last Quarter" {
+		t.Errorf("Phase was incorrect, got: %+v, want: Last Quarter 0.7548 0.4848", phase)
+	}
+}
+
+func TestMoonBetween(t *testing.T) {
+	// a night spanning the moonrise of March 4th and the moonset of the 5th
+	start := time.Date(2013, 3, 4, 20, 0, 0, 0, time.UTC)
+	end := time.Date(2013, 3, 5, 12, 0, 0, 0, time.UTC)
+	moon := MoonBetween(start, end, 50.5, 30.5)
+
+	if d := moon.Moonrise.Sub(time.Date(2013, 3, 4, 23, 54, 29, 0, time.UTC)); d < -5*time.Minute || d > 5*time.Minute {
+		t.Errorf("Moonrise was incorrect, got: %s, want: about 23:54 on March 4th", moon.Moonrise)
+	}
+	if moon.Moonset.Before(time.Date(2013, 3, 5, 7, 0, 0, 0, time.UTC)) || !moon.Moonset.Before(end) {
+		t.Errorf("Moonset was incorrect, got: %s, want: the morning of March 5th", moon.Moonset)
+	}
+	if moon.Phase.Name != "Last Quarter" {
+		t.Errorf("Phase was incorrect, got: %s, want: Last Quarter", moon.Phase.Name)
+	}
+}
+
+func TestMoonPhaseNames(t *testing.T) {
+	cases := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2020, 6, 5, 19, 12, 0, 0, time.UTC), "Full Moon"},
+		{time.Date(2020, 6, 21, 6, 41, 0, 0, time.UTC), "New Moon"},
+		{time.Date(2020, 6, 28, 8, 16, 0, 0, time.UTC), "First Quarter"},
+		{time.Date(2020, 6, 9, 12, 0, 0, 0, time.UTC), "Waning Gibbous"},
+	}
+	for _, c := range cases {
+		if got := Phase(c.date); got.Name != c.want {
+			t.Errorf("Phase at %s was incorrect, got: %s, want: %s", c.date, got.Name, c.want)
+		}
+	}
+	if full := Phase(time.Date(2020, 6, 5, 19, 12, 0, 0, time.UTC)); full.Illumination < 0.99 {
+		t.Errorf("Full moon illumination was incorrect, got: %v, want: about 1", full.Illumination)
+	}
+}
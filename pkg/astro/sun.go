@@ -0,0 +1,110 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Julian date of the J2000.0 epoch, 2000-01-01T12:00:00Z
+const j2000 = 2451545.0
+
+// The J2000.0 epoch
+var j2000Epoch = time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// Obliquity of the ecliptic in degrees
+const obliquity = 23.4397
+
+// Altitudes in degrees of the center of the Sun at each solar event
+// sunrise and sunset allow for atmospheric refraction and the radius
+// of the Sun
+const (
+	sunriseAltitude      = -0.833
+	civilAltitude        = -6.0
+	nauticalAltitude     = -12.0
+	astronomicalAltitude = -18.0
+)
+
+// SunTimes are the times of the solar events of a day at a location
+// An event is the zero time when it does not happen that day, e.g. the
+// Sun neither rises nor sets during the polar day and night
+// DayLength is the time between sunrise and sunset: 24 hours during the
+// polar day and none during the polar night
+type SunTimes struct {
+	Sunrise          time.Time
+	Sunset           time.Time
+	SolarNoon        time.Time
+	CivilDawn        time.Time
+	CivilDusk        time.Time
+	NauticalDawn     time.Time
+	NauticalDusk     time.Time
+	AstronomicalDawn time.Time
+	AstronomicalDusk time.Time
+	DayLength        time.Duration
+}
+
+// Sun computes the SunTimes of the calendar day of date at the given
+// coordinates, in the time zone of date
+// It uses the NOAA sunrise equation, accurate to about a minute outside
+// of the polar regions
+func Sun(date time.Time, lat, lng float64) SunTimes {
+	loc := date.Location()
+	// days since J2000.0 of the mean solar noon at the longitude
+	jStar := dayNumber(date) - lng/360
+	m := normalize(357.5291 + 0.98560028*jStar)
+	center := 1.9148*sin(m) + 0.0200*sin(2*m) + 0.0003*sin(3*m)
+	lambda := normalize(m + center + 180 + 102.9372)
+	transit := j2000 + jStar + 0.0053*sin(m) - 0.0069*sin(2*lambda)
+	declination := deg(math.Asin(sin(lambda) * sin(obliquity)))
+
+	times := SunTimes{SolarNoon: fromJulian(transit, loc)}
+	event := func(altitude float64) (time.Time, time.Time, float64) {
+		cosOmega := (sin(altitude) - sin(lat)*sin(declination)) / (cos(lat) * cos(declination))
+		if cosOmega < -1 || cosOmega > 1 {
+			return time.Time{}, time.Time{}, cosOmega
+		}
+		omega := deg(math.Acos(cosOmega))
+		return fromJulian(transit-omega/360, loc), fromJulian(transit+omega/360, loc), cosOmega
+	}
+	var cosOmega float64
+	times.Sunrise, times.Sunset, cosOmega = event(sunriseAltitude)
+	times.CivilDawn, times.CivilDusk, _ = event(civilAltitude)
+	times.NauticalDawn, times.NauticalDusk, _ = event(nauticalAltitude)
+	times.AstronomicalDawn, times.AstronomicalDusk, _ = event(astronomicalAltitude)
+	switch {
+	case cosOmega < -1:
+		times.DayLength = 24 * time.Hour
+	case cosOmega <= 1:
+		times.DayLength = times.Sunset.Sub(times.Sunrise)
+	}
+	return times
+}
+
+// dayNumber returns the number of days from J2000.0 to noon UTC of the
+// calendar day of t
+func dayNumber(t time.Time) float64 {
+	y, m, d := t.Date()
+	return math.Round(time.Date(y, m, d, 12, 0, 0, 0, time.UTC).Sub(j2000Epoch).Hours() / 24)
+}
+
+// fromJulian converts a Julian date to a time in loc, to the second
+func fromJulian(j float64, loc *time.Location) time.Time {
+	offset := time.Duration((j - j2000) * 24 * float64(time.Hour))
+	return j2000Epoch.Add(offset).Round(time.Second).In(loc)
+}
+
+// normalize reduces an angle in degrees to [0, 360)
+func normalize(degrees float64) float64 {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
+
+func rad(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+func deg(radians float64) float64 { return radians * 180 / math.Pi }
+
+func sin(degrees float64) float64 { return math.Sin(rad(degrees)) }
+
+func cos(degrees float64) float64 { return math.Cos(rad(degrees)) }
@@ -0,0 +1,66 @@
+package astro
+
+import (
+	"testing"
+	"time"
+)
+
+// Coordinates of Chicago, IL and Utqiagvik, AK
+const (
+	chicagoLat, chicagoLng     = 41.8781, -87.6298
+	utqiagvikLat, utqiagvikLng = 71.2906, -156.7886
+)
+
+var (
+	cdt  = time.FixedZone("CDT", -5*3600)
+	cst  = time.FixedZone("CST", -6*3600)
+	akdt = time.FixedZone("AKDT", -8*3600)
+)
+
+// within reports whether got is within two minutes of want
+func within(got, want time.Time) bool {
+	d := got.Sub(want)
+	return d > -2*time.Minute && d < 2*time.Minute
+}
+
+func TestSun(t *testing.T) {
+	summer := Sun(time.Date(2020, 6, 20, 9, 30, 0, 0, cdt), chicagoLat, chicagoLng)
+	winter := Sun(time.Date(2020, 12, 21, 23, 0, 0, 0, cst), chicagoLat, chicagoLng)
+	// times published by the NOAA solar calculator
+	cases := []struct {
+		name      string
+		got, want time.Time
+	}{
+		{"summer sunrise", summer.Sunrise, time.Date(2020, 6, 20, 5, 15, 0, 0, cdt)},
+		{"summer solar noon", summer.SolarNoon, time.Date(2020, 6, 20, 12, 52, 0, 0, cdt)},
+		{"summer sunset", summer.Sunset, time.Date(2020, 6, 20, 20, 29, 0, 0, cdt)},
+		{"summer civil dusk", summer.CivilDusk, time.Date(2020, 6, 20, 21, 3, 0, 0, cdt)},
+		{"winter sunrise", winter.Sunrise, time.Date(2020, 12, 21, 7, 15, 0, 0, cst)},
+		{"winter sunset", winter.Sunset, time.Date(2020, 12, 21, 16, 22, 0, 0, cst)},
+	}
+	for _, c := range cases {
+		if !within(c.got, c.want) {
+			t.Errorf("%s was incorrect, got: %s, want: %s", c.name, c.got, c.want)
+		}
+	}
+	if summer.Sunrise.Location() != cdt {
+		t.Errorf("Sun was not in the time zone of date, got: %s", summer.Sunrise.Location())
+	}
+	if summer.DayLength < 15*time.Hour+12*time.Minute || summer.DayLength > 15*time.Hour+16*time.Minute {
+		t.Errorf("summer day length was incorrect, got: %s, want: about 15h14m", summer.DayLength)
+	}
+}
+
+func TestSunPolar(t *testing.T) {
+	day := Sun(time.Date(2020, 6, 21, 12, 0, 0, 0, akdt), utqiagvikLat, utqiagvikLng)
+	if !day.Sunrise.IsZero() || !day.Sunset.IsZero() || day.DayLength != 24*time.Hour {
+		t.Errorf("polar day was incorrect, got: sunrise %s, sunset %s, day length %s", day.Sunrise, day.Sunset, day.DayLength)
+	}
+	night := Sun(time.Date(2020, 12, 21, 12, 0, 0, 0, akdt), utqiagvikLat, utqiagvikLng)
+	if !night.Sunrise.IsZero() || !night.Sunset.IsZero() || night.DayLength != 0 {
+		t.Errorf("polar night was incorrect, got: sunrise %s, sunset %s, day length %s", night.Sunrise, night.Sunset, night.DayLength)
+	}
+	if night.CivilDawn.IsZero() || night.CivilDusk.IsZero() {
+		t.Errorf("polar night had no civil twilight, got: dawn %s, dusk %s", night.CivilDawn, night.CivilDusk)
+	}
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+)
+
+// ErrCacheMiss is returned when a key is absent or expired
+var ErrCacheMiss = errors.New("cache miss")
+
+// ErrWrongType is returned when a key holds a list but a string was
+// requested, or vice versa
+var ErrWrongType = errs.Wrap(errs.ErrCacheUnavailable, errors.New("cache key holds the wrong type of value"))
+
+// ErrExpired is returned when a value would expire before it is stored
+var ErrExpired = errs.Wrap(errs.ErrInternal, errors.New("cache value has already expired"))
+
+// ForecastCache is a key/value store with expiring string and list values
+// Get and GetList return ErrCacheMiss for keys that do not exist
+// GetList follows the semantics of Redis' LRANGE: start and stop are
+// inclusive and negative indices count back from the end of the list
+// Set and SetList refuse with ErrExpired a ttl of 0 or less or an
+// expireAt that is not in the future, rather than storing forever
+// SetList replaces any list previously stored under key
+// Failures to reach the backing store are errs.ErrCacheUnavailable
+type ForecastCache interface {
+	Get(key string) (string, error)
+	Set(key string, value string, ttl time.Duration) error
+	GetList(key string, start, stop int64) ([]string, error)
+	SetList(key string, values []string, expireAt time.Time) error
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Default maximum number of keys held by a MemoryCache
+const defaultMemoryCacheSize = 10000
+
+// entry is a single key held by a MemoryCache
+// exactly one of value or values is used, depending on isList
+type entry struct {
+	key      string
+	value    string
+	values   []string
+	isList   bool
+	expireAt time.Time
+}
+
+// MemoryCache is an in-process ForecastCache for single instance
+// deployments and tests
+// Keys expire after their TTL, and once Size keys are held the least
+// recently used key is evicted to make room for a new one
+type MemoryCache struct {
+	Size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+// NewMemoryCache creates a MemoryCache holding at most size keys
+// a size of 0 or less uses the default size
+func NewMemoryCache(size int) *MemoryCache {
+	if size <= 0 {
+		size = defaultMemoryCacheSize
+	}
+	return &MemoryCache{
+		Size:    size,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the string stored at key
+func (mc *MemoryCache) Get(key string) (string, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	e, ok := mc.lookup(key)
+	if !ok {
+		return "", ErrCacheMiss
+	}
+	if e.isList {
+		return "", ErrWrongType
+	}
+	return e.value, nil
+}
+
+// Set stores value at key for the duration of ttl
+func (mc *MemoryCache) Set(key string, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrExpired
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.store(&entry{key: key, value: value, expireAt: mc.now().Add(ttl)})
+	return nil
+}
+
+// GetList returns the elements of the list at key between start and stop
+func (mc *MemoryCache) GetList(key string, start, stop int64) ([]string, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	e, ok := mc.lookup(key)
+	if !ok {
+		return []string{}, ErrCacheMiss
+	}
+	if !e.isList {
+		return []string{}, ErrWrongType
+	}
+	n := int64(len(e.values))
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop {
+		return []string{}, ErrCacheMiss
+	}
+	vals := make([]string, stop-start+1)
+	copy(vals, e.values[start:stop+1])
+	return vals, nil
+}
+
+// SetList replaces the list at key with values, expiring at expireAt
+func (mc *MemoryCache) SetList(key string, values []string, expireAt time.Time) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if len(values) == 0 {
+		mc.remove(key)
+		return nil
+	}
+	if !expireAt.After(mc.now()) {
+		mc.remove(key)
+		return ErrExpired
+	}
+	vals := make([]string, len(values))
+	copy(vals, values)
+	mc.store(&entry{key: key, values: vals, isList: true, expireAt: expireAt})
+	return nil
+}
+
+// lookup returns the live entry at key, marking it as recently used
+// expired entries are removed
+func (mc *MemoryCache) lookup(key string) (*entry, bool) {
+	elem, ok := mc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := elem.Value.(*entry)
+	if !mc.now().Before(e.expireAt) {
+		mc.remove(key)
+		return nil, false
+	}
+	mc.lru.MoveToFront(elem)
+	return e, true
+}
+
+// store inserts or replaces an entry, evicting the least recently used
+// entry when the cache is full
+func (mc *MemoryCache) store(e *entry) {
+	if elem, ok := mc.entries[e.key]; ok {
+		elem.Value = e
+		mc.lru.MoveToFront(elem)
+		return
+	}
+	for mc.lru.Len() >= mc.Size {
+		oldest := mc.lru.Back()
+		mc.remove(oldest.Value.(*entry).key)
+	}
+	mc.entries[e.key] = mc.lru.PushFront(e)
+}
+
+func (mc *MemoryCache) remove(key string) {
+	if elem, ok := mc.entries[key]; ok {
+		mc.lru.Remove(elem)
+		delete(mc.entries, key)
+	}
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+)
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	now := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+	mc := NewMemoryCache(10)
+	mc.now = func() time.Time { return now }
+
+	_ = mc.Set("chicago_il_monday", "Sunny", time.Hour)
+
+	if val, err := mc.Get("chicago_il_monday"); err != nil || val != "Sunny" {
+		t.Errorf("Value was incorrect, got: %s (%v), want: Sunny", val, err)
+	}
+
+	now = now.Add(time.Hour)
+
+	if _, err := mc.Get("chicago_il_monday"); err != ErrCacheMiss {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, ErrCacheMiss)
+	}
+
+	if err := mc.Set("chicago_il_sunday", "Rain", 0); err != ErrExpired {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, ErrExpired)
+	}
+	if err := mc.SetList("chicago_il_hourly", []string{"1"}, now); err != ErrExpired {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, ErrExpired)
+	}
+	if _, err := mc.Get("chicago_il_sunday"); err != ErrCacheMiss {
+		t.Errorf("Expired value was stored, got: %v", err)
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	mc := NewMemoryCache(2)
+
+	_ = mc.Set("a", "1", time.Hour)
+	_ = mc.Set("b", "2", time.Hour)
+	_, _ = mc.Get("a")
+	_ = mc.Set("c", "3", time.Hour)
+
+	if _, err := mc.Get("b"); err != ErrCacheMiss {
+		t.Errorf("Least recently used key was not evicted, got: %v", err)
+	}
+	if _, err := mc.Get("a"); err != nil {
+		t.Errorf("Recently used key was evicted, got: %v", err)
+	}
+}
+
+func TestMemoryCacheList(t *testing.T) {
+	mc := NewMemoryCache(10)
+
+	_ = mc.SetList("chicago_il_hourly", []string{"1", "2", "3"}, time.Now().Add(time.Hour))
+	_ = mc.SetList("chicago_il_hourly", []string{"4", "5", "6", "7"}, time.Now().Add(time.Hour))
+
+	vals, err := mc.GetList("chicago_il_hourly", 0, 1)
+	if err != nil || len(vals) != 2 || vals[0] != "4" || vals[1] != "5" {
+		t.Errorf("List was incorrect, got: %v (%v), want: [4 5]", vals, err)
+	}
+
+	vals, _ = mc.GetList("chicago_il_hourly", 0, 99)
+	if len(vals) != 4 {
+		t.Errorf("List length was incorrect, got: %d, want: 4", len(vals))
+	}
+
+	if vals, err := mc.GetList("chicago_il_hourly", 5, 9); err != ErrCacheMiss || len(vals) != 0 {
+		t.Errorf("Out of range list was incorrect, got: %v (%v), want: [] (%v)", vals, err, ErrCacheMiss)
+	}
+
+	_, err = mc.Get("chicago_il_hourly")
+	if err != ErrWrongType {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, ErrWrongType)
+	}
+	if !errors.Is(err, errs.ErrCacheUnavailable) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrCacheUnavailable)
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+)
+
+// RedisCache is the ForecastCache backed by a Redis server
+type RedisCache struct {
+	Client *redis.Client
+}
+
+// NewRedisCache creates a RedisCache using the given client
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{Client: client}
+}
+
+// Get returns the string stored at key
+func (rc *RedisCache) Get(key string) (string, error) {
+	val, err := rc.Client.Get(key).Result()
+	if err == redis.Nil {
+		return "", ErrCacheMiss
+	} else if err != nil {
+		return "", errs.Wrap(errs.ErrCacheUnavailable, err)
+	}
+	return val, nil
+}
+
+// Set stores value at key for the duration of ttl
+func (rc *RedisCache) Set(key string, value string, ttl time.Duration) error {
+	// Redis would keep a value set with a ttl of 0 forever
+	if ttl <= 0 {
+		return ErrExpired
+	}
+	if err := rc.Client.Set(key, value, ttl).Err(); err != nil {
+		return errs.Wrap(errs.ErrCacheUnavailable, err)
+	}
+	return nil
+}
+
+// GetList returns the elements of the list at key between start and stop
+func (rc *RedisCache) GetList(key string, start, stop int64) ([]string, error) {
+	val, err := rc.Client.LRange(key, start, stop).Result()
+	if err != nil {
+		return []string{}, errs.Wrap(errs.ErrCacheUnavailable, err)
+	}
+	// len(val) == 0 means key does not exist
+	if len(val) == 0 {
+		return []string{}, ErrCacheMiss
+	}
+	return val, nil
+}
+
+// SetList atomically replaces the list at key with values, expiring at expireAt
+func (rc *RedisCache) SetList(key string, values []string, expireAt time.Time) error {
+	if len(values) > 0 && !expireAt.After(time.Now()) {
+		if err := rc.Client.Del(key).Err(); err != nil {
+			return errs.Wrap(errs.ErrCacheUnavailable, err)
+		}
+		return ErrExpired
+	}
+	elems := make([]interface{}, len(values))
+	for i, v := range values {
+		elems[i] = v
+	}
+	pipe := rc.Client.TxPipeline()
+	pipe.Del(key)
+	if len(elems) > 0 {
+		pipe.RPush(key, elems...)
+		pipe.ExpireAt(key, expireAt)
+	}
+	if _, err := pipe.Exec(); err != nil {
+		return errs.Wrap(errs.ErrCacheUnavailable, err)
+	}
+	return nil
+}
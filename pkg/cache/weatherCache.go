@@ -1,34 +1,197 @@
 package cache
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/go-redis/redis"
-
 	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
 	"github.com/kylep342/thorcast-server/pkg/utils"
 )
 
-// CacheDetailedForecasts stores the provided forecasts
-// for the given City, State, and Period
-// key format is city.asKey_state.asKey_period.asKey
+// CachedPeriod is a forecast period as stored in the cache
+// GeneratedAt is when the forecast containing the period was generated
+type CachedPeriod struct {
+	apis.ForecastPeriod
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// GridCell identifies the weather.gov grid cell of a location
+// Forecasts are cached by grid cell, so that every location in a cell
+// shares them
+type GridCell struct {
+	Office string `json:"office"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+}
+
+// NewGridCell returns the GridCell of a grid point
+func NewGridCell(g models.GridPoint) GridCell {
+	return GridCell{Office: g.Cwa, X: g.GridX, Y: g.GridY}
+}
+
+// Key returns the GridCell formatted for use in a cache key
+func (gc GridCell) Key() string {
+	return fmt.Sprintf("%s_%d_%d", strings.ToLower(gc.Office), gc.X, gc.Y)
+}
+
+// forecastKey returns the prefix of the keys of the forecasts for the
+// given GridCell in the given System of units
+func forecastKey(cell GridCell, system units.System) string {
+	return fmt.Sprintf("%s_%s", cell.Key(), system)
+}
+
+// How long the grid cell of a city and state is cached
+// It is 30 times shorter than the service's stored grid point refresh
+// because nothing checks a cached grid cell: it is only read for
+// locations without a stored grid point, so a wrong one would serve
+// another cell's forecasts until it expires
+// A day bounds that, and still spares a /points call on nearly every
+// forecast cache miss
+const gridCellTTL = 24 * time.Hour
+
+// CacheGridCell stores the GridCell the given City and State lie in
+// key format is city.Key()_state.Key()_gridcell
+func CacheGridCell(c ForecastCache, city utils.City, state utils.State, cell GridCell) {
+	key := fmt.Sprintf(
+		"%s_%s_gridcell",
+		city.Key(),
+		state.Key())
+	val, _ := json.Marshal(cell)
+	err := c.Set(key, string(val), gridCellTTL)
+	if err != nil {
+		log.Printf("Error occurred when setting a grid cell in the cache\nError is: %s\n", err.Error())
+	}
+}
+
+// LookupGridCell tries to retrieve the GridCell the given City and State
+// lie in from the cache
+// ErrCacheMiss is returned if it is not cached
+func LookupGridCell(c ForecastCache, city utils.City, state utils.State) (GridCell, error) {
+	key := fmt.Sprintf(
+		"%s_%s_gridcell",
+		city.Key(),
+		state.Key())
+	val, err := c.Get(key)
+	if err != nil {
+		return GridCell{}, err
+	}
+	var cell GridCell
+	if err := json.Unmarshal([]byte(val), &cell); err != nil {
+		log.Printf("Error decoding cached grid cell at %s\nError is: %s\n", key, err.Error())
+		return GridCell{}, ErrCacheMiss
+	}
+	return cell, nil
+}
+
+// CachePoints stores the weather.gov Points of the given City and State
+// key format is city.Key()_state.Key()_points
+func CachePoints(c ForecastCache, city utils.City, state utils.State, p apis.Points) {
+	key := fmt.Sprintf(
+		"%s_%s_points",
+		city.Key(),
+		state.Key())
+	val, _ := json.Marshal(p)
+	err := c.Set(key, string(val), gridCellTTL)
+	if err != nil {
+		log.Printf("Error occurred when setting points in the cache\nError is: %s\n", err.Error())
+	}
+}
+
+// LookupPoints tries to retrieve the weather.gov Points of the given City
+// and State from the cache
+// ErrCacheMiss is returned if they are not cached
+func LookupPoints(c ForecastCache, city utils.City, state utils.State) (apis.Points, error) {
+	key := fmt.Sprintf(
+		"%s_%s_points",
+		city.Key(),
+		state.Key())
+	val, err := c.Get(key)
+	if err != nil {
+		return apis.Points{}, err
+	}
+	var p apis.Points
+	if err := json.Unmarshal([]byte(val), &p); err != nil {
+		log.Printf("Error decoding cached points at %s\nError is: %s\n", key, err.Error())
+		return apis.Points{}, ErrCacheMiss
+	}
+	return p, nil
+}
+
+// pointsKey returns the key of the weather.gov Points of the given
+// coordinates
+func pointsKey(c models.Coordinates) string {
+	return fmt.Sprintf("points_%.4f_%.4f", c.Lat, c.Lng)
+}
+
+// CachePointsAt stores the weather.gov Points of the given coordinates
+// key format is points_lat_lng
+func CachePointsAt(c ForecastCache, coordinates models.Coordinates, p apis.Points) {
+	val, _ := json.Marshal(p)
+	err := c.Set(pointsKey(coordinates), string(val), gridCellTTL)
+	if err != nil {
+		log.Printf("Error occurred when setting points in the cache\nError is: %s\n", err.Error())
+	}
+}
+
+// LookupPointsAt tries to retrieve the weather.gov Points of the given
+// coordinates from the cache
+// ErrCacheMiss is returned if they are not cached
+func LookupPointsAt(c ForecastCache, coordinates models.Coordinates) (apis.Points, error) {
+	key := pointsKey(coordinates)
+	val, err := c.Get(key)
+	if err != nil {
+		return apis.Points{}, err
+	}
+	var p apis.Points
+	if err := json.Unmarshal([]byte(val), &p); err != nil {
+		log.Printf("Error decoding cached points at %s\nError is: %s\n", key, err.Error())
+		return apis.Points{}, ErrCacheMiss
+	}
+	return p, nil
+}
+
+// encodePeriod serializes a forecast period for storage in the cache
+func encodePeriod(p apis.ForecastPeriod, generatedAt time.Time) string {
+	val, _ := json.Marshal(CachedPeriod{ForecastPeriod: p, GeneratedAt: generatedAt})
+	return string(val)
+}
+
+// decodePeriod deserializes a forecast period stored in the cache
+// values which cannot be decoded (e.g. written by an older version of
+// thorcast) are treated as cache misses
+func decodePeriod(key, val string) (CachedPeriod, error) {
+	var p CachedPeriod
+	if err := json.Unmarshal([]byte(val), &p); err != nil {
+		log.Printf("Error decoding cached forecast at %s\nError is: %s\n", key, err.Error())
+		return CachedPeriod{}, ErrCacheMiss
+	}
+	return p, nil
+}
+
+// CacheDetailedForecasts stores every period of the provided forecasts
+// for the given GridCell and System
+// key format is cell.Key()_system_period.Key()
 func CacheDetailedForecasts(
-	cache *redis.Client
-	city utils.City,
-	state utils.State,
-	period utils.Period,
-	forecasts apis.Forecasts
-) string {
+	c ForecastCache,
+	cell GridCell,
+	system units.System,
+	forecasts apis.Forecasts,
+) {
 	now := time.Now().UTC()
-	var detailedForecast string
+	generatedAt := forecasts.Properties.GeneratedAt
 	for _, forecast := range forecasts.Properties.Periods {
-		// log.Printf("Start time is: %s, end time is: %s\n", forecast.StartTime, forecast.EndTime)
 		fcStartTime, _ := time.Parse(time.RFC3339, forecast.StartTime)
-		fcEndTime, _ := time.Parse(time.RFC3339, forecast.EndTime)
-		// log.Printf("fcStartTime is: %v, fcEndTime is: %v\n", fcStartTime, fcEndTime)
+		fcEndTime, err := time.Parse(time.RFC3339, forecast.EndTime)
+		// ended periods would expire before they are stored
+		if err != nil || !fcEndTime.After(now) {
+			continue
+		}
 		dayOfWeek := fcStartTime.Weekday().String()
 		var timeOfDay string
 		if forecast.IsDaytime {
@@ -37,107 +200,471 @@ func CacheDetailedForecasts(
 			timeOfDay = "_night"
 		}
 		key := fmt.Sprintf(
-			"%s_%s_%s%s",
-			city.asKey,
-			state.asKey,
+			"%s_%s%s",
+			forecastKey(cell, system),
 			strings.ToLower(dayOfWeek),
 			timeOfDay)
-		// log.Printf("Key is %s\n", key)
-		err := cache.Set(
+		err = c.Set(
 			key,
-			forecast.DetailedForecast,
-			fcEndTime.Sub(now)).Err()
+			encodePeriod(forecast, generatedAt),
+			fcEndTime.Sub(now))
 		if err != nil {
-			log.Printf("Error occurred when setting a detailedForecast in Redis\nError is: %s\n", err.Error())
+			log.Printf("Error occurred when setting a detailedForecast in the cache\nError is: %s\n", err.Error())
 		}
-		// log.Printf("dayOfWeek is %s, period DOW is %s. fcDayTime is %t, periodDayTime is %t\n", dayOfWeek, period.dayOfWeek, forecast.IsDaytime, period.isDaytime)
-		if dayOfWeek == period.dayOfWeek && forecast.IsDaytime == period.isDaytime {
-			detailedForecast = forecast.DetailedForecast
+	}
+}
+
+// MatchDetailedForecast returns the period of forecasts matching period,
+// and found reports whether there was one
+func MatchDetailedForecast(forecasts apis.Forecasts, period utils.Period) (detailedForecast CachedPeriod, found bool) {
+	for _, forecast := range forecasts.Properties.Periods {
+		fcStartTime, _ := time.Parse(time.RFC3339, forecast.StartTime)
+		if fcStartTime.Weekday().String() == period.DayOfWeek() && forecast.IsDaytime == period.IsDaytime() {
+			detailedForecast = CachedPeriod{ForecastPeriod: forecast, GeneratedAt: forecasts.Properties.GeneratedAt}
+			found = true
 		}
 	}
-	return detailedForecast
+	return detailedForecast, found
 }
 
 // LookupDetailedForecast tries to retrieve the forecast from the cache
-// for the given City, State, and Period
+// for the given GridCell, System and Period
+// ErrCacheMiss is returned if it is not cached
 func LookupDetailedForecast(
-	cache *redis.Client
-	city utils.City,
-	state utils.State,
-	period utils.Period
-) (string, error) {
+	c ForecastCache,
+	cell GridCell,
+	system units.System,
+	period utils.Period,
+) (CachedPeriod, error) {
 	key := fmt.Sprintf(
-		"%s_%s_%s",
-		city.asKey,
-		state.asKey,
-		period.asKey)
-	val, err := cache.Get(key).Result()
+		"%s_%s",
+		forecastKey(cell, system),
+		period.Key())
+	val, err := c.Get(key)
 	if err != nil {
-		return "", err
+		return CachedPeriod{}, err
 	}
-	return val, nil
+	return decodePeriod(key, val)
 }
 
-// CacheHourlyForecasts persists all hourly forecasts in Redis as a list
-// with an expiry of one hour
+// Weekdays in the order used by period keys
+var weekdays = []time.Weekday{
+	time.Sunday,
+	time.Monday,
+	time.Tuesday,
+	time.Wednesday,
+	time.Thursday,
+	time.Friday,
+	time.Saturday,
+}
+
+// LookupWeekForecast tries to retrieve every period of the detailed
+// forecast for the given GridCell and System from the cache, in order
+// The periods must be contiguous, start with the period in effect at now,
+// and all be from the same forecast, or else ErrCacheMiss is returned
+func LookupWeekForecast(
+	c ForecastCache,
+	cell GridCell,
+	system units.System,
+	now time.Time,
+) ([]CachedPeriod, error) {
+	var periods []CachedPeriod
+	for _, day := range weekdays {
+		for _, timeOfDay := range []string{"", "_night"} {
+			key := fmt.Sprintf(
+				"%s_%s%s",
+				forecastKey(cell, system),
+				strings.ToLower(day.String()),
+				timeOfDay)
+			val, err := c.Get(key)
+			if err == ErrCacheMiss {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			p, err := decodePeriod(key, val)
+			if err != nil {
+				return nil, err
+			}
+			periods = append(periods, p)
+		}
+	}
+	if len(periods) == 0 {
+		return nil, ErrCacheMiss
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].StartTime < periods[j].StartTime })
+	first, _ := time.Parse(time.RFC3339, periods[0].StartTime)
+	if first.After(now) {
+		return nil, ErrCacheMiss
+	}
+	for i := 1; i < len(periods); i++ {
+		if periods[i].StartTime != periods[i-1].EndTime || !periods[i].GeneratedAt.Equal(periods[0].GeneratedAt) {
+			return nil, ErrCacheMiss
+		}
+	}
+	return periods, nil
+}
+
+// AllPeriods returns every period of forecasts
+func AllPeriods(forecasts apis.Forecasts) []CachedPeriod {
+	periods := make([]CachedPeriod, 0, len(forecasts.Properties.Periods))
+	for _, fc := range forecasts.Properties.Periods {
+		periods = append(periods, CachedPeriod{ForecastPeriod: fc, GeneratedAt: forecasts.Properties.GeneratedAt})
+	}
+	return periods
+}
+
+// CacheHourlyForecasts persists all hourly forecasts in the cache as a list
+// for the given GridCell and System with an expiry of one hour
 func CacheHourlyForecasts(
-	cache *redis.Client
-	city utils.City,
-	state utils.State,
-	hours int64,
-	forecasts apis.Forecasts
-) []string {
+	c ForecastCache,
+	cell GridCell,
+	system units.System,
+	forecasts apis.Forecasts,
+) {
 	key := fmt.Sprintf(
-		"%s_%s_hourly",
-		city.asKey,
-		state.asKey)
+		"%s_hourly",
+		forecastKey(cell, system))
 	now := time.Now().UTC()
 	expiry := now.Add(1 * time.Hour).Truncate(1 * time.Hour)
-	var hourlyForecasts []string
+	var encoded []string
 	for _, fc := range forecasts.Properties.Periods {
-		fcDate, _ := time.Parse(time.RFC3339, fc.StartTime)
-		forecast := fmt.Sprintf(
-			"%s Forecast: %s, Temperature: %d\u00B0 %s, Wind: %s %s",
-			fcDate.Format(time.RFC3339),
-			fc.ShortForecast,
-			int(fc.Temperature),
-			fc.TemperatureUnit,
-			fc.WindSpeed,
-			fc.WindDirection)
-		hourlyForecasts = append(hourlyForecasts, forecast)
+		encoded = append(encoded, encodePeriod(fc, forecasts.Properties.GeneratedAt))
 	}
-	err := cache.RPush(key, hourlyForecasts).Err()
+	err := c.SetList(key, encoded, expiry)
 	if err != nil {
-		log.Printf("Error occurred when appending an hourly forecast to a list\nError is: %s\n", err.Error())
+		log.Printf("Error occurred when storing hourly forecasts as a list\nError is: %s\n", err.Error())
 	}
-	err = cache.ExpireAt(key, expiry).Err()
-	if err != nil {
-		log.Printf("Error occurred when setting an expiry for a list\nError is: %s\n", err.Error())
+}
+
+// FirstHourlyForecasts returns the first hours periods of forecasts
+func FirstHourlyForecasts(forecasts apis.Forecasts, hours int64) []CachedPeriod {
+	periods := forecasts.Properties.Periods
+	if hours > int64(len(periods)) {
+		hours = int64(len(periods))
+	}
+	hourlyForecasts := make([]CachedPeriod, 0, hours)
+	for _, fc := range periods[:hours] {
+		hourlyForecasts = append(hourlyForecasts, CachedPeriod{ForecastPeriod: fc, GeneratedAt: forecasts.Properties.GeneratedAt})
 	}
-	return hourlyForecasts[:hours]
+	return hourlyForecasts
 }
 
-// LookupHourlyForecast checks Redis for the requested city, state pair over
-// the given hour range
+// LookupHourlyForecast checks the cache for the requested GridCell and
+// System over the given hour range
 // If a key is found, it returns the requested number of hourly forecasts
 func LookupHourlyForecast(
-	cache *redis.Client,
+	c ForecastCache,
+	cell GridCell,
+	system units.System,
+	hours int64,
+) ([]CachedPeriod, error) {
+	key := fmt.Sprintf(
+		"%s_hourly",
+		forecastKey(cell, system))
+	val, err := c.GetList(key, 0, hours-1)
+	if err != nil {
+		if err != ErrCacheMiss {
+			log.Printf("Error occurred when reading hourly forecasts from a list\nError is: %s\n", err.Error())
+		}
+		return nil, err
+	}
+	hourlyForecasts := make([]CachedPeriod, 0, len(val))
+	for _, v := range val {
+		p, err := decodePeriod(key, v)
+		if err != nil {
+			return nil, err
+		}
+		hourlyForecasts = append(hourlyForecasts, p)
+	}
+	return hourlyForecasts, nil
+}
+
+// How long the last fetched forecasts of a location are kept to be served
+// while the forecast provider is unavailable
+const staleTTL = 24 * time.Hour
+
+// staleKey returns the key of the last fetched forecasts of a product
+// for the given GridCell and System
+func staleKey(cell GridCell, system units.System, product string) string {
+	return fmt.Sprintf(
+		"%s_%s_stale",
+		forecastKey(cell, system),
+		product)
+}
+
+// CacheStaleForecasts keeps the forecasts of a product for the given
+// GridCell and System for a day, past the expiry of the individual
+// periods, as a fallback for when they cannot be fetched again
+func CacheStaleForecasts(
+	c ForecastCache,
+	cell GridCell,
+	system units.System,
+	product string,
+	forecasts apis.Forecasts,
+) {
+	val, err := json.Marshal(forecasts)
+	if err != nil {
+		log.Printf("Error encoding stale forecasts\nError is: %s\n", err.Error())
+		return
+	}
+	err = c.Set(staleKey(cell, system, product), string(val), staleTTL)
+	if err != nil {
+		log.Printf("Error occurred when setting stale forecasts in the cache\nError is: %s\n", err.Error())
+	}
+}
+
+// LookupStaleForecasts retrieves the last fetched forecasts of a product
+// for the given GridCell and System
+// ErrCacheMiss is returned if there are none
+func LookupStaleForecasts(
+	c ForecastCache,
+	cell GridCell,
+	system units.System,
+	product string,
+) (apis.Forecasts, error) {
+	key := staleKey(cell, system, product)
+	val, err := c.Get(key)
+	if err != nil {
+		return apis.Forecasts{}, err
+	}
+	var forecasts apis.Forecasts
+	if err := json.Unmarshal([]byte(val), &forecasts); err != nil {
+		log.Printf("Error decoding stale forecasts at %s\nError is: %s\n", key, err.Error())
+		return apis.Forecasts{}, ErrCacheMiss
+	}
+	return forecasts, nil
+}
+
+// CacheGridData stores the raw grid data of the given GridCell
+// with an expiry of one hour
+func CacheGridData(
+	c ForecastCache,
+	cell GridCell,
+	g apis.GridData,
+) {
+	key := fmt.Sprintf(
+		"%s_grid",
+		cell.Key())
+	val, err := json.Marshal(g)
+	if err != nil {
+		log.Printf("Error encoding grid data\nError is: %s\n", err.Error())
+		return
+	}
+	now := time.Now().UTC()
+	expiry := now.Add(1 * time.Hour).Truncate(1 * time.Hour)
+	err = c.Set(key, string(val), expiry.Sub(now))
+	if err != nil {
+		log.Printf("Error occurred when setting grid data in the cache\nError is: %s\n", err.Error())
+	}
+}
+
+// LookupGridData tries to retrieve the raw grid data of the given
+// GridCell from the cache
+// ErrCacheMiss is returned if it is not cached
+func LookupGridData(
+	c ForecastCache,
+	cell GridCell,
+) (apis.GridData, error) {
+	key := fmt.Sprintf(
+		"%s_grid",
+		cell.Key())
+	val, err := c.Get(key)
+	if err != nil {
+		return apis.GridData{}, err
+	}
+	var g apis.GridData
+	if err := json.Unmarshal([]byte(val), &g); err != nil {
+		log.Printf("Error decoding cached grid data at %s\nError is: %s\n", key, err.Error())
+		return apis.GridData{}, ErrCacheMiss
+	}
+	return g, nil
+}
+
+// How long alerts are cached; alerts can be issued at any time, so they
+// are only cached long enough to absorb bursts of requests
+const alertsTTL = 2 * time.Minute
+
+// alertsKey returns the key of the alerts in effect at the given coordinates
+func alertsKey(c models.Coordinates) string {
+	return fmt.Sprintf("alerts_%.4f_%.4f", c.Lat, c.Lng)
+}
+
+// CacheAlerts stores the alerts in effect at the given coordinates
+// with an expiry of two minutes
+func CacheAlerts(c ForecastCache, coordinates models.Coordinates, alerts apis.Alerts) {
+	val, err := json.Marshal(alerts)
+	if err != nil {
+		log.Printf("Error encoding alerts\nError is: %s\n", err.Error())
+		return
+	}
+	err = c.Set(alertsKey(coordinates), string(val), alertsTTL)
+	if err != nil {
+		log.Printf("Error occurred when setting alerts in the cache\nError is: %s\n", err.Error())
+	}
+}
+
+// LookupAlerts tries to retrieve the alerts in effect at the given
+// coordinates from the cache
+// ErrCacheMiss is returned if they are not cached
+func LookupAlerts(c ForecastCache, coordinates models.Coordinates) (apis.Alerts, error) {
+	key := alertsKey(coordinates)
+	val, err := c.Get(key)
+	if err != nil {
+		return apis.Alerts{}, err
+	}
+	var alerts apis.Alerts
+	if err := json.Unmarshal([]byte(val), &alerts); err != nil {
+		log.Printf("Error decoding cached alerts at %s\nError is: %s\n", key, err.Error())
+		return apis.Alerts{}, ErrCacheMiss
+	}
+	return alerts, nil
+}
+
+// How long the latest observation of a location is cached; stations
+// report roughly hourly
+const observationTTL = 10 * time.Minute
+
+// CachedObservation is the latest observation of the station chosen for
+// a location, as stored in the cache
+// Distance is the distance in kilometers from the location to the station
+type CachedObservation struct {
+	Station     apis.Station     `json:"station"`
+	Distance    float64          `json:"distance"`
+	Observation apis.Observation `json:"observation"`
+}
+
+// CacheObservation stores the latest observation for the given City and
+// State with an expiry of ten minutes
+func CacheObservation(
+	c ForecastCache,
 	city utils.City,
 	state utils.State,
-	hours int64
-) ([]string, error) {
+	o CachedObservation,
+) {
+	key := fmt.Sprintf(
+		"%s_%s_observation",
+		city.Key(),
+		state.Key())
+	val, err := json.Marshal(o)
+	if err != nil {
+		log.Printf("Error encoding observation\nError is: %s\n", err.Error())
+		return
+	}
+	err = c.Set(key, string(val), observationTTL)
+	if err != nil {
+		log.Printf("Error occurred when setting an observation in the cache\nError is: %s\n", err.Error())
+	}
+}
+
+// LookupObservation tries to retrieve the latest observation for the
+// given City and State from the cache
+// ErrCacheMiss is returned if it is not cached
+func LookupObservation(
+	c ForecastCache,
+	city utils.City,
+	state utils.State,
+) (CachedObservation, error) {
+	key := fmt.Sprintf(
+		"%s_%s_observation",
+		city.Key(),
+		state.Key())
+	val, err := c.Get(key)
+	if err != nil {
+		return CachedObservation{}, err
+	}
+	var o CachedObservation
+	if err := json.Unmarshal([]byte(val), &o); err != nil {
+		log.Printf("Error decoding cached observation at %s\nError is: %s\n", key, err.Error())
+		return CachedObservation{}, ErrCacheMiss
+	}
+	return o, nil
+}
+
+// observationKey returns the key of the latest observation cached for the
+// given coordinates
+func observationKey(c models.Coordinates) string {
+	return fmt.Sprintf("observation_%.4f_%.4f", c.Lat, c.Lng)
+}
+
+// CacheObservationAt stores the latest observation for the given
+// coordinates with an expiry of ten minutes
+// key format is observation_lat_lng
+func CacheObservationAt(c ForecastCache, coordinates models.Coordinates, o CachedObservation) {
+	val, err := json.Marshal(o)
+	if err != nil {
+		log.Printf("Error encoding observation\nError is: %s\n", err.Error())
+		return
+	}
+	err = c.Set(observationKey(coordinates), string(val), observationTTL)
+	if err != nil {
+		log.Printf("Error occurred when setting an observation in the cache\nError is: %s\n", err.Error())
+	}
+}
+
+// LookupObservationAt tries to retrieve the latest observation for the
+// given coordinates from the cache
+// ErrCacheMiss is returned if it is not cached
+func LookupObservationAt(c ForecastCache, coordinates models.Coordinates) (CachedObservation, error) {
+	key := observationKey(coordinates)
+	val, err := c.Get(key)
+	if err != nil {
+		return CachedObservation{}, err
+	}
+	var o CachedObservation
+	if err := json.Unmarshal([]byte(val), &o); err != nil {
+		log.Printf("Error decoding cached observation at %s\nError is: %s\n", key, err.Error())
+		return CachedObservation{}, ErrCacheMiss
+	}
+	return o, nil
+}
+
+// AFDs are routinely issued a few times a day, so one is cached until
+// discussionInterval after its issuance, and past that rechecked every
+// discussionRecheck until the next is issued
+const (
+	discussionInterval = 6 * time.Hour
+	discussionRecheck  = 10 * time.Minute
+)
+
+// CacheDiscussion stores the latest Area Forecast Discussion of the given
+// forecast office until its next issuance is expected
+// key format is office_discussion
+func CacheDiscussion(c ForecastCache, office string, p apis.Product) {
+	key := fmt.Sprintf(
+		"%s_discussion",
+		strings.ToLower(office))
+	val, err := json.Marshal(p)
+	if err != nil {
+		log.Printf("Error encoding discussion\nError is: %s\n", err.Error())
+		return
+	}
+	now := time.Now().UTC()
+	expiry := p.IssuanceTime.Add(discussionInterval)
+	if expiry.Before(now.Add(discussionRecheck)) {
+		expiry = now.Add(discussionRecheck)
+	}
+	err = c.Set(key, string(val), expiry.Sub(now))
+	if err != nil {
+		log.Printf("Error occurred when setting a discussion in the cache\nError is: %s\n", err.Error())
+	}
+}
+
+// LookupDiscussion tries to retrieve the latest Area Forecast Discussion
+// of the given forecast office from the cache
+// ErrCacheMiss is returned if it is not cached
+func LookupDiscussion(c ForecastCache, office string) (apis.Product, error) {
 	key := fmt.Sprintf(
-		"%s_%s_hourly",
-		city.asKey,
-		state.asKey)
-	val, err := cache.LRange(key, 0, hours-1).Result()
+		"%s_discussion",
+		strings.ToLower(office))
+	val, err := c.Get(key)
 	if err != nil {
-		log.Printf("Error occurred when reading hourly forecasts from a list\nError is: %s\n", err.Error())
-		return []string{}, err
+		return apis.Product{}, err
 	}
-	// len(val) == 0 means key does not exist
-	if len(val) > 0 {
-		return val, nil
+	var p apis.Product
+	if err := json.Unmarshal([]byte(val), &p); err != nil {
+		log.Printf("Error decoding cached discussion at %s\nError is: %s\n", key, err.Error())
+		return apis.Product{}, ErrCacheMiss
 	}
-	return []string{}, redis.Nil
+	return p, nil
 }
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+// PostgresStore is the LocationStore and PreferenceStore backed by the
+// geocodex and preferences tables in Postgres (see db/models/ddl.sql)
+type PostgresStore struct {
+	DB *sql.DB
+}
+
+// NewPostgresStore creates a PostgresStore using the given connection pool
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+// Lookup reads the coordinates of a city, state pair from geocodex
+func (ps *PostgresStore) Lookup(city, state string) (models.Location, error) {
+	query := `SELECT` + locationColumns + `
+		FROM geocodex
+		WHERE LOWER(city) = LOWER($1)
+		AND state = $2
+		;`
+	row := ps.DB.QueryRow(query, city, state)
+	l, err := scanLocation(row)
+	if err != nil && !errors.Is(err, ErrLocationNotFound) {
+		log.Printf("Error scanning lat/lng from the database: %s\n", err.Error())
+	}
+	return l, err
+}
+
+// Register persists a city, state, lat, lng group in the database
+func (ps *PostgresStore) Register(l models.Location) error {
+	insertStmt := `
+	INSERT INTO geocodex (city, state, lat, lng, requests)
+	VALUES ($1, $2, $3, $4, 1)
+	ON CONFLICT ON CONSTRAINT geocodex_pkey DO UPDATE
+	SET requests = geocodex.requests+1
+	`
+	_, err := ps.DB.Exec(insertStmt, l.City, l.State, l.Lat, l.Lng)
+	if err != nil {
+		log.Printf("An unexpected error occurred when inserting into geocodex\nError is: %s\n", err.Error())
+		return errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Increment increments the requests counter of a location already stored in the database
+func (ps *PostgresStore) Increment(l models.Location) error {
+	updateStmt := `
+	UPDATE geocodex
+	SET requests = requests+1
+	WHERE LOWER(city) = LOWER($1)
+	AND state = $2`
+	_, err := ps.DB.Exec(updateStmt, l.City, l.State)
+	if err != nil {
+		log.Printf("An unexpected error occurred when updating requests in geocodex\nError is: %s\n", err.Error())
+		return errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Random selects a random location from geocodex
+func (ps *PostgresStore) Random() (models.Location, error) {
+	row := ps.DB.QueryRow(
+		`SELECT` + locationColumns + `
+		FROM geocodex
+		ORDER BY random()
+		LIMIT 1;`)
+	l, err := scanLocation(row)
+	if err != nil && !errors.Is(err, ErrLocationNotFound) {
+		log.Printf("Error when reading geocodex information from the database.\nError is %s\n", err.Error())
+	}
+	return l, err
+}
+
+// List reads every location in geocodex, most requested first
+func (ps *PostgresStore) List() ([]models.Location, error) {
+	rows, err := ps.DB.Query(
+		`SELECT` + locationColumns + `
+		FROM geocodex
+		ORDER BY requests DESC, state, city;`)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return scanLocations(rows)
+}
+
+// SetGridPoint stores the grid point of a location already stored in the database
+func (ps *PostgresStore) SetGridPoint(l models.Location) error {
+	updateStmt := `
+	UPDATE geocodex
+	SET cwa = $3,
+		grid_x = $4,
+		grid_y = $5,
+		forecast_url = $6,
+		forecast_hourly_url = $7,
+		forecast_grid_data_url = $8,
+		observation_stations_url = $9,
+		time_zone = $10,
+		forecast_zone = $11,
+		county = $12,
+		radar_station = $13,
+		points_updated_at = $14
+	WHERE LOWER(city) = LOWER($1)
+	AND state = $2`
+	args := append([]interface{}{l.City, l.State}, gridPointArgs(l.GridPoint)...)
+	_, err := ps.DB.Exec(updateStmt, args...)
+	if err != nil {
+		log.Printf("An unexpected error occurred when updating the grid point in geocodex\nError is: %s\n", err.Error())
+		return errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Units reads the System of units a user prefers from preferences
+func (ps *PostgresStore) Units(user string) (units.System, error) {
+	var system string
+	err := ps.DB.QueryRow(`SELECT units FROM preferences WHERE user_id = $1;`, user).Scan(&system)
+	if err == sql.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		log.Printf("Error reading preferences from the database: %s\n", err.Error())
+		return "", errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return units.System(system), nil
+}
+
+// SetUnits persists the System of units a user prefers in preferences
+func (ps *PostgresStore) SetUnits(user string, system units.System) error {
+	insertStmt := `
+	INSERT INTO preferences (user_id, units)
+	VALUES ($1, $2)
+	ON CONFLICT ON CONSTRAINT preferences_pkey DO UPDATE
+	SET units = excluded.units
+	`
+	_, err := ps.DB.Exec(insertStmt, user, string(system))
+	if err != nil {
+		log.Printf("An unexpected error occurred when inserting into preferences\nError is: %s\n", err.Error())
+		return errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return nil
+}
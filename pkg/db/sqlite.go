@@ -0,0 +1,246 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	// registers the sqlite3 driver with database/sql
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+// Schema of the geocodex and preferences tables for SQLite, mirroring
+// db/models/ddl.sql
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS geocodex (
+	city VARCHAR NOT NULL,
+	state VARCHAR(2) NOT NULL,
+	lat REAL NOT NULL CHECK (lat BETWEEN -90.0 AND 90.0),
+	lng REAL NOT NULL CHECK (lng BETWEEN -180.0 AND 180.0),
+	requests INTEGER CHECK (requests > 0),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (city, state)
+);
+
+CREATE TABLE IF NOT EXISTS preferences (
+	user_id VARCHAR NOT NULL,
+	units VARCHAR(2) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id)
+);
+`
+
+// Columns added to geocodex after its creation, and their types
+// SQLite cannot add a column only if it does not exist, so they are
+// added to databases created before them by migrateSQLite
+var sqliteAddedColumns = [][2]string{
+	{"cwa", "VARCHAR(3)"},
+	{"grid_x", "INTEGER"},
+	{"grid_y", "INTEGER"},
+	{"forecast_url", "VARCHAR"},
+	{"forecast_hourly_url", "VARCHAR"},
+	{"forecast_grid_data_url", "VARCHAR"},
+	{"observation_stations_url", "VARCHAR"},
+	{"time_zone", "VARCHAR"},
+	{"forecast_zone", "VARCHAR"},
+	{"county", "VARCHAR"},
+	{"radar_station", "VARCHAR"},
+	{"points_updated_at", "TIMESTAMP"},
+}
+
+// SQLiteStore is the LocationStore and PreferenceStore backed by an embedded SQLite database
+// for small deployments and tests
+type SQLiteStore struct {
+	DB *sql.DB
+}
+
+// NewSQLiteStore opens (creating if needed) the SQLite database at path
+// and ensures the geocodex table exists
+// path may be ":memory:" for a throwaway database
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	// SQLite serializes writers, and each connection to ":memory:"
+	// would otherwise be a separate database
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return &SQLiteStore{DB: db}, nil
+}
+
+// migrateSQLite adds any of sqliteAddedColumns missing from geocodex
+func migrateSQLite(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('geocodex');")
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, column := range sqliteAddedColumns {
+		if existing[column[0]] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE geocodex ADD COLUMN %s %s;", column[0], column[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup reads the coordinates of a city, state pair from geocodex
+func (ss *SQLiteStore) Lookup(city, state string) (models.Location, error) {
+	query := `SELECT` + locationColumns + `
+		FROM geocodex
+		WHERE LOWER(city) = LOWER(?)
+		AND state = ?
+		;`
+	row := ss.DB.QueryRow(query, city, state)
+	l, err := scanLocation(row)
+	if err != nil && !errors.Is(err, ErrLocationNotFound) {
+		log.Printf("Error scanning lat/lng from the database: %s\n", err.Error())
+	}
+	return l, err
+}
+
+// Register persists a city, state, lat, lng group in the database
+func (ss *SQLiteStore) Register(l models.Location) error {
+	insertStmt := `
+	INSERT INTO geocodex (city, state, lat, lng, requests)
+	VALUES (?, ?, ?, ?, 1)
+	ON CONFLICT (city, state) DO UPDATE
+	SET requests = requests+1,
+		updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := ss.DB.Exec(insertStmt, l.City, l.State, l.Lat, l.Lng)
+	if err != nil {
+		log.Printf("An unexpected error occurred when inserting into geocodex\nError is: %s\n", err.Error())
+		return errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Increment increments the requests counter of a location already stored in the database
+func (ss *SQLiteStore) Increment(l models.Location) error {
+	updateStmt := `
+	UPDATE geocodex
+	SET requests = requests+1,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE LOWER(city) = LOWER(?)
+	AND state = ?`
+	_, err := ss.DB.Exec(updateStmt, l.City, l.State)
+	if err != nil {
+		log.Printf("An unexpected error occurred when updating requests in geocodex\nError is: %s\n", err.Error())
+		return errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Random selects a random location from geocodex
+func (ss *SQLiteStore) Random() (models.Location, error) {
+	row := ss.DB.QueryRow(
+		`SELECT` + locationColumns + `
+		FROM geocodex
+		ORDER BY random()
+		LIMIT 1;`)
+	l, err := scanLocation(row)
+	if err != nil && !errors.Is(err, ErrLocationNotFound) {
+		log.Printf("Error when reading geocodex information from the database.\nError is %s\n", err.Error())
+	}
+	return l, err
+}
+
+// List reads every location in geocodex, most requested first
+func (ss *SQLiteStore) List() ([]models.Location, error) {
+	rows, err := ss.DB.Query(
+		`SELECT` + locationColumns + `
+		FROM geocodex
+		ORDER BY requests DESC, state, city;`)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return scanLocations(rows)
+}
+
+// SetGridPoint stores the grid point of a location already stored in the database
+func (ss *SQLiteStore) SetGridPoint(l models.Location) error {
+	updateStmt := `
+	UPDATE geocodex
+	SET cwa = ?,
+		grid_x = ?,
+		grid_y = ?,
+		forecast_url = ?,
+		forecast_hourly_url = ?,
+		forecast_grid_data_url = ?,
+		observation_stations_url = ?,
+		time_zone = ?,
+		forecast_zone = ?,
+		county = ?,
+		radar_station = ?,
+		points_updated_at = ?,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE LOWER(city) = LOWER(?)
+	AND state = ?`
+	args := append(gridPointArgs(l.GridPoint), l.City, l.State)
+	_, err := ss.DB.Exec(updateStmt, args...)
+	if err != nil {
+		log.Printf("An unexpected error occurred when updating the grid point in geocodex\nError is: %s\n", err.Error())
+		return errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Units reads the System of units a user prefers from preferences
+func (ss *SQLiteStore) Units(user string) (units.System, error) {
+	var system string
+	err := ss.DB.QueryRow(`SELECT units FROM preferences WHERE user_id = ?;`, user).Scan(&system)
+	if err == sql.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		log.Printf("Error reading preferences from the database: %s\n", err.Error())
+		return "", errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return units.System(system), nil
+}
+
+// SetUnits persists the System of units a user prefers in preferences
+func (ss *SQLiteStore) SetUnits(user string, system units.System) error {
+	insertStmt := `
+	INSERT INTO preferences (user_id, units)
+	VALUES (?, ?)
+	ON CONFLICT (user_id) DO UPDATE
+	SET units = excluded.units,
+		updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := ss.DB.Exec(insertStmt, user, string(system))
+	if err != nil {
+		log.Printf("An unexpected error occurred when inserting into preferences\nError is: %s\n", err.Error())
+		return errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+func newTestStore(t *testing.T) *SQLiteStore {
+	store, err := NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sqlite: %s", err.Error())
+	}
+	return store
+}
+
+func TestSQLiteStoreLookup(t *testing.T) {
+	store := newTestStore(t)
+	target := models.Location{City: "Chicago", State: "IL", Lat: 41.8781, Lng: -87.6298}
+
+	if _, err := store.Lookup("chicago", "IL"); !errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, ErrLocationNotFound)
+	}
+
+	if err := store.Register(target); err != nil {
+		t.Fatalf("Unexpected error registering location: %s", err.Error())
+	}
+
+	l, err := store.Lookup("chicago", "IL")
+	if err != nil || l != target {
+		t.Errorf("Location was incorrect, got: %v (%v), want: %v", l, err, target)
+	}
+}
+
+func TestSQLiteStoreList(t *testing.T) {
+	store := newTestStore(t)
+	chicago := models.Location{City: "Chicago", State: "IL", Lat: 41.8781, Lng: -87.6298}
+	boise := models.Location{City: "Boise", State: "ID", Lat: 43.6150, Lng: -116.2023}
+
+	_ = store.Register(boise)
+	_ = store.Register(chicago)
+	_ = store.Increment(chicago)
+
+	locations, err := store.List()
+	if err != nil || len(locations) != 2 || locations[0] != chicago {
+		t.Errorf("Locations were incorrect, got: %v (%v), want Chicago first", locations, err)
+	}
+
+	if _, err := store.Random(); err != nil {
+		t.Errorf("Unexpected error selecting a random location: %s", err.Error())
+	}
+}
+
+func TestSQLiteStoreSetGridPoint(t *testing.T) {
+	store := newTestStore(t)
+	chicago := models.Location{City: "Chicago", State: "IL", Lat: 41.8781, Lng: -87.6298}
+	_ = store.Register(chicago)
+
+	chicago.GridPoint = models.GridPoint{
+		Cwa:               "LOT",
+		GridX:             76,
+		GridY:             73,
+		ForecastURL:       "https://api.weather.gov/gridpoints/LOT/76,73/forecast",
+		ForecastHourlyURL: "https://api.weather.gov/gridpoints/LOT/76,73/forecast/hourly",
+		TimeZone:          "America/Chicago",
+		UpdatedAt:         time.Date(2020, 6, 2, 15, 4, 5, 0, time.UTC),
+	}
+	if err := store.SetGridPoint(chicago); err != nil {
+		t.Fatalf("Unexpected error setting grid point: %s", err.Error())
+	}
+
+	l, err := store.Lookup("Chicago", "IL")
+	if err != nil || l != chicago || !l.HasGridPoint() {
+		t.Errorf("Location was incorrect, got: %v (%v), want: %v", l, err, chicago)
+	}
+}
+
+func TestSQLiteStoreMigratesGridPointColumns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thorcast")
+	if err != nil {
+		t.Fatalf("Unexpected error creating a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "thorcast.db")
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening sqlite: %s", err.Error())
+	}
+	_, err = old.Exec(`
+	CREATE TABLE geocodex (
+		city VARCHAR NOT NULL,
+		state VARCHAR(2) NOT NULL,
+		lat REAL NOT NULL,
+		lng REAL NOT NULL,
+		requests INTEGER,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (city, state)
+	);
+	INSERT INTO geocodex (city, state, lat, lng, requests) VALUES ('Boise', 'ID', 43.615, -116.2023, 1);`)
+	old.Close()
+	if err != nil {
+		t.Fatalf("Unexpected error creating the old schema: %s", err.Error())
+	}
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error migrating sqlite: %s", err.Error())
+	}
+	l, err := store.Lookup("Boise", "ID")
+	if err != nil || l.HasGridPoint() {
+		t.Errorf("Location was incorrect, got: %v (%v)", l, err)
+	}
+}
+
+func TestSQLiteStoreUnits(t *testing.T) {
+	store := newTestStore(t)
+
+	if system, err := store.Units("U123"); err != nil || system != "" {
+		t.Errorf("Units were incorrect, got: %q (%v), want: none", system, err)
+	}
+
+	for _, target := range []units.System{units.SI, units.US} {
+		if err := store.SetUnits("U123", target); err != nil {
+			t.Fatalf("Unexpected error storing units: %s", err.Error())
+		}
+		if system, err := store.Units("U123"); err != nil || system != target {
+			t.Errorf("Units were incorrect, got: %q (%v), want: %q", system, err, target)
+		}
+	}
+}
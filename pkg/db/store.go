@@ -0,0 +1,135 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+// ErrLocationNotFound is returned when a location is not stored in geocodex
+var ErrLocationNotFound = errs.ErrLocationNotFound
+
+// LocationStore persists geocoded locations (the geocodex table)
+// Lookup matches city case insensitively and returns ErrLocationNotFound
+// if no row exists
+// Any other failure is an errs.ErrStorageUnavailable
+// Register inserts a location, counting a request if it already exists
+// Increment counts a request for a location already stored
+// Random returns a random stored location
+// List returns all stored locations, most requested first
+// SetGridPoint stores the GridPoint of a location already stored
+type LocationStore interface {
+	Lookup(city, state string) (models.Location, error)
+	Register(l models.Location) error
+	Increment(l models.Location) error
+	Random() (models.Location, error)
+	List() ([]models.Location, error)
+	SetGridPoint(l models.Location) error
+}
+
+// PreferenceStore persists the preferences of users (the preferences table)
+// users are identified by an opaque id chosen by the frontend
+// Units returns an empty System if the user has not chosen one
+// SetUnits stores the System of units a user prefers
+// Any failure is an errs.ErrStorageUnavailable
+type PreferenceStore interface {
+	Units(user string) (units.System, error)
+	SetUnits(user string, system units.System) error
+}
+
+// Columns of geocodex read into a Location by scanLocation
+// grid point columns are NULL until the grid point of a location is known
+const locationColumns = `
+			city,
+			state,
+			lat,
+			lng,
+			COALESCE(cwa, ''),
+			COALESCE(grid_x, 0),
+			COALESCE(grid_y, 0),
+			COALESCE(forecast_url, ''),
+			COALESCE(forecast_hourly_url, ''),
+			COALESCE(forecast_grid_data_url, ''),
+			COALESCE(observation_stations_url, ''),
+			COALESCE(time_zone, ''),
+			COALESCE(forecast_zone, ''),
+			COALESCE(county, ''),
+			COALESCE(radar_station, ''),
+			points_updated_at`
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLocation reads a row of locationColumns into a Location
+func scanLocation(row scanner) (models.Location, error) {
+	var l models.Location
+	var updatedAt *time.Time
+	g := &l.GridPoint
+	err := row.Scan(
+		&l.City,
+		&l.State,
+		&l.Lat,
+		&l.Lng,
+		&g.Cwa,
+		&g.GridX,
+		&g.GridY,
+		&g.ForecastURL,
+		&g.ForecastHourlyURL,
+		&g.ForecastGridDataURL,
+		&g.ObservationStationsURL,
+		&g.TimeZone,
+		&g.ForecastZone,
+		&g.County,
+		&g.RadarStation,
+		&updatedAt)
+	if err == sql.ErrNoRows {
+		return models.Location{}, ErrLocationNotFound
+	} else if err != nil {
+		return models.Location{}, errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	if updatedAt != nil {
+		g.UpdatedAt = updatedAt.UTC()
+	}
+	return l, nil
+}
+
+// gridPointArgs returns the values of the grid point columns of a Location,
+// in the order of locationColumns
+func gridPointArgs(g models.GridPoint) []interface{} {
+	return []interface{}{
+		g.Cwa,
+		g.GridX,
+		g.GridY,
+		g.ForecastURL,
+		g.ForecastHourlyURL,
+		g.ForecastGridDataURL,
+		g.ObservationStationsURL,
+		g.TimeZone,
+		g.ForecastZone,
+		g.County,
+		g.RadarStation,
+		g.UpdatedAt.UTC(),
+	}
+}
+
+// scanLocations reads every row of locationColumns into a slice of Locations
+func scanLocations(rows *sql.Rows) ([]models.Location, error) {
+	defer rows.Close()
+	var locations []models.Location
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.Wrap(errs.ErrStorageUnavailable, err)
+	}
+	return locations, nil
+}
@@ -0,0 +1,114 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code is a stable, machine readable identifier for a kind of error
+type Code string
+
+// Codes of every kind of error thorcast reports to clients
+const (
+	CodeInvalidState        Code = "invalid_state"
+	CodeInvalidPeriod       Code = "invalid_period"
+	CodeInvalidHours        Code = "invalid_hours"
+	CodeInvalidCoordinates  Code = "invalid_coordinates"
+	CodeInvalidFormat       Code = "invalid_format"
+	CodeInvalidUnits        Code = "invalid_units"
+	CodeInvalidUser         Code = "invalid_user"
+	CodeInvalidDate         Code = "invalid_date"
+	CodeLocationNotFound    Code = "location_not_found"
+	CodePeriodUnavailable   Code = "period_unavailable"
+	CodeOutOfCoverage       Code = "out_of_coverage"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeUpstreamError       Code = "upstream_error"
+	CodeStorageUnavailable  Code = "storage_unavailable"
+	CodeCacheUnavailable    Code = "cache_unavailable"
+	CodeNotFound            Code = "not_found"
+	CodeInternal            Code = "internal"
+)
+
+// Error is a typed error
+// Code identifies the kind of error, Message describes the kind of error
+// to clients, Detail describes this occurrence of it to clients (if known),
+// Param names the request parameter at fault (if any), and Err is the
+// underlying cause (if any), which is never shown to clients
+// errors.Is matches any two Errors with the same Code
+type Error struct {
+	Code    Code
+	Message string
+	Detail  string
+	Param   string
+	Err     error
+}
+
+// Kinds of errors, returned as is or wrapped around a cause with Wrap
+var (
+	ErrInvalidState        = &Error{Code: CodeInvalidState, Message: "Invalid state name.", Param: "state"}
+	ErrInvalidPeriod       = &Error{Code: CodeInvalidPeriod, Message: "Invalid period.", Param: "period"}
+	ErrInvalidHours        = &Error{Code: CodeInvalidHours, Message: "Invalid number of hours.", Param: "hours"}
+	ErrInvalidCoordinates  = &Error{Code: CodeInvalidCoordinates, Message: "Invalid coordinates.", Param: "lat"}
+	ErrInvalidFormat       = &Error{Code: CodeInvalidFormat, Message: "Invalid format.", Param: "format"}
+	ErrInvalidUnits        = &Error{Code: CodeInvalidUnits, Message: "Invalid units.", Param: "units"}
+	ErrInvalidUser         = &Error{Code: CodeInvalidUser, Message: "Invalid user.", Param: "X-Thorcast-User"}
+	ErrInvalidDate         = &Error{Code: CodeInvalidDate, Message: "Invalid date.", Param: "date"}
+	ErrLocationNotFound    = &Error{Code: CodeLocationNotFound, Message: "Location not found.", Param: "city"}
+	ErrPeriodUnavailable   = &Error{Code: CodePeriodUnavailable, Message: "Forecast period unavailable.", Param: "period"}
+	ErrOutOfCoverage       = &Error{Code: CodeOutOfCoverage, Message: "Location is outside of forecast coverage."}
+	ErrUpstreamUnavailable = &Error{Code: CodeUpstreamUnavailable, Message: "Upstream service unavailable."}
+	ErrUpstream            = &Error{Code: CodeUpstreamError, Message: "Invalid response from upstream service."}
+	ErrStorageUnavailable  = &Error{Code: CodeStorageUnavailable, Message: "Storage unavailable."}
+	ErrCacheUnavailable    = &Error{Code: CodeCacheUnavailable, Message: "Cache unavailable."}
+	ErrNotFound            = &Error{Code: CodeNotFound, Message: "Not found."}
+	ErrInternal            = &Error{Code: CodeInternal, Message: "Internal error."}
+)
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Err.Error())
+}
+
+// Unwrap returns the underlying cause of the Error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an Error with the same Code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of kind caused by err
+func Wrap(kind *Error, err error) *Error {
+	e := *kind
+	e.Err = err
+	return &e
+}
+
+// WithDetail returns a copy of kind described by detail
+func WithDetail(kind *Error, detail string) *Error {
+	e := *kind
+	e.Detail = detail
+	return &e
+}
+
+// WithParam returns a copy of kind blaming the request parameter param
+func WithParam(kind *Error, param string) *Error {
+	e := *kind
+	e.Param = param
+	return &e
+}
+
+// As returns the first Error in err's chain, treating any untyped error
+// as an internal error
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Wrap(ErrInternal, err)
+}
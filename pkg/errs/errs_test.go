@@ -0,0 +1,31 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrapMatchesKind(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("fetching points: %w", Wrap(ErrUpstreamUnavailable, cause))
+
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("Wrapped error did not match its kind: %v", err)
+	}
+	if errors.Is(err, ErrUpstream) {
+		t.Errorf("Wrapped error matched another kind: %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("Wrapped error did not match its cause: %v", err)
+	}
+}
+
+func TestAsDefaultsToInternal(t *testing.T) {
+	if code := As(errors.New("boom")).Code; code != CodeInternal {
+		t.Errorf("Code was incorrect, got: %s, want: %s", code, CodeInternal)
+	}
+	if code := As(Wrap(ErrInvalidPeriod, nil)).Code; code != CodeInvalidPeriod {
+		t.Errorf("Code was incorrect, got: %s, want: %s", code, CodeInvalidPeriod)
+	}
+}
@@ -6,13 +6,51 @@
 
 package models
 
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Mean radius of the Earth in kilometers
+const earthRadius = 6371.0
+
 // Location corresponds to a row in the geocodex table
 // The only fields that are read/written by the app are below
+// GridPoint is the weather.gov grid point of the location, if known
+// Label describes a location requested by coordinates alone, which is
+// never stored, by the city weather.gov reports nearest to it
 type Location struct {
-	City  string  `db:"city"`
-	State string  `db:"state"`
-	Lat   float64 `db:"lat"`
-	Lng   float64 `db:"lng"`
+	City      string  `db:"city"`
+	State     string  `db:"state"`
+	Lat       float64 `db:"lat"`
+	Lng       float64 `db:"lng"`
+	GridPoint GridPoint
+	Label     string
+}
+
+// GridPoint is the weather.gov forecast metadata of a location, as
+// returned by api.weather.gov/points
+// UpdatedAt is when it was fetched, and is zero if it never was
+type GridPoint struct {
+	Cwa                    string    `db:"cwa"`
+	GridX                  int       `db:"grid_x"`
+	GridY                  int       `db:"grid_y"`
+	ForecastURL            string    `db:"forecast_url"`
+	ForecastHourlyURL      string    `db:"forecast_hourly_url"`
+	ForecastGridDataURL    string    `db:"forecast_grid_data_url"`
+	ObservationStationsURL string    `db:"observation_stations_url"`
+	TimeZone               string    `db:"time_zone"`
+	ForecastZone           string    `db:"forecast_zone"`
+	County                 string    `db:"county"`
+	RadarStation           string    `db:"radar_station"`
+	UpdatedAt              time.Time `db:"points_updated_at"`
+}
+
+// Coordinates holds a lat, lng pair
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
 }
 
 // SetLocationCoordinates will set the Lat and Lng values for a Location
@@ -20,3 +58,30 @@ func (l *Location) SetLocationCoordinates(o Coordinates) {
 	l.Lat = o.Lat
 	l.Lng = o.Lng
 }
+
+// DistanceTo returns the great-circle distance in kilometers between
+// two Coordinates
+func (c Coordinates) DistanceTo(o Coordinates) float64 {
+	lat1, lat2 := c.Lat*math.Pi/180, o.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (o.Lng - c.Lng) * math.Pi / 180
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Name describes the Location: its city and state if it is stored, or
+// else its Label, or else its coordinates
+func (l Location) Name() string {
+	if l.City != "" {
+		return fmt.Sprintf("%s, %s", l.City, l.State)
+	}
+	if l.Label != "" {
+		return l.Label
+	}
+	return fmt.Sprintf("%.4f, %.4f", l.Lat, l.Lng)
+}
+
+// HasGridPoint reports whether the GridPoint of the Location is known
+func (l Location) HasGridPoint() bool {
+	return !l.GridPoint.UpdatedAt.IsZero()
+}
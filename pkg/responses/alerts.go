@@ -0,0 +1,54 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/models"
+)
+
+// Alert is a weather alert in effect at a location
+// Onset and Expires are null when weather.gov does not give them
+type Alert struct {
+	ID            string     `json:"id"`
+	Event         string     `json:"event"`
+	Severity      string     `json:"severity"`
+	Urgency       string     `json:"urgency"`
+	Certainty     string     `json:"certainty"`
+	Headline      string     `json:"headline"`
+	Description   string     `json:"description"`
+	Instruction   string     `json:"instruction"`
+	Onset         *time.Time `json:"onset"`
+	Expires       *time.Time `json:"expires"`
+	AreaDesc      string     `json:"areaDesc"`
+	AffectedZones []string   `json:"affectedZones"`
+}
+
+// Alerts is the body of a /api/alerts response
+type Alerts struct {
+	Location Location `json:"location"`
+	Alerts   []Alert  `json:"alerts"`
+}
+
+// NewAlerts creates an Alerts from a location and the weather.gov alerts
+// in effect there
+func NewAlerts(l models.Location, alerts []apis.Alert) Alerts {
+	resp := Alerts{Location: NewLocation(l), Alerts: make([]Alert, 0, len(alerts))}
+	for _, a := range alerts {
+		resp.Alerts = append(resp.Alerts, Alert{
+			ID:            a.ID,
+			Event:         a.Event,
+			Severity:      a.Severity,
+			Urgency:       a.Urgency,
+			Certainty:     a.Certainty,
+			Headline:      a.Headline,
+			Description:   a.Description,
+			Instruction:   a.Instruction,
+			Onset:         a.Onset,
+			Expires:       a.Expires,
+			AreaDesc:      a.AreaDesc,
+			AffectedZones: a.AffectedZones,
+		})
+	}
+	return resp
+}
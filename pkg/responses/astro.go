@@ -0,0 +1,108 @@
+package responses
+
+import (
+	"math"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/astro"
+	"github.com/kylep342/thorcast-server/pkg/models"
+)
+
+// Sun is the times of the solar events of a day
+// Times are omitted when the event does not happen that day, e.g. during
+// the polar day and night
+// DayLength is the time between sunrise and sunset, in minutes
+type Sun struct {
+	Sunrise          *time.Time `json:"sunrise,omitempty"`
+	Sunset           *time.Time `json:"sunset,omitempty"`
+	SolarNoon        *time.Time `json:"solarNoon,omitempty"`
+	CivilDawn        *time.Time `json:"civilDawn,omitempty"`
+	CivilDusk        *time.Time `json:"civilDusk,omitempty"`
+	NauticalDawn     *time.Time `json:"nauticalDawn,omitempty"`
+	NauticalDusk     *time.Time `json:"nauticalDusk,omitempty"`
+	AstronomicalDawn *time.Time `json:"astronomicalDawn,omitempty"`
+	AstronomicalDusk *time.Time `json:"astronomicalDusk,omitempty"`
+	DayLength        Quantity   `json:"dayLength"`
+}
+
+// Moon is the times of the lunar events of a day and the phase of the Moon
+// Times are omitted when the event does not happen that day; AlwaysUp and
+// AlwaysDown report whether the Moon stays above or below the horizon
+// Illumination is the percentage of its disk lit by the Sun
+type Moon struct {
+	Moonrise     *time.Time `json:"moonrise,omitempty"`
+	Moonset      *time.Time `json:"moonset,omitempty"`
+	AlwaysUp     bool       `json:"alwaysUp,omitempty"`
+	AlwaysDown   bool       `json:"alwaysDown,omitempty"`
+	Phase        string     `json:"phase"`
+	Illumination Quantity   `json:"illumination"`
+}
+
+// Astro is the body of a /api/astro response
+// Date is the requested day (YYYY-MM-DD) and TimeZone the time zone of
+// its times
+type Astro struct {
+	Location Location `json:"location"`
+	Date     string   `json:"date"`
+	TimeZone string   `json:"timeZone"`
+	Sun      *Sun     `json:"sun"`
+	Moon     *Moon    `json:"moon"`
+}
+
+// NewAstro creates an Astro from a stored location, the midnight starting
+// the requested day and the SunTimes and MoonTimes of that day
+func NewAstro(l models.Location, date time.Time, sun astro.SunTimes, moon astro.MoonTimes) Astro {
+	return Astro{
+		Location: NewLocation(l),
+		Date:     date.Format("2006-01-02"),
+		TimeZone: date.Location().String(),
+		Sun:      NewSun(sun),
+		Moon:     NewMoon(moon),
+	}
+}
+
+// NewSun creates a Sun from the SunTimes of a day
+// It returns nil for the zero SunTimes, whose day is unknown
+func NewSun(s astro.SunTimes) *Sun {
+	if s.SolarNoon.IsZero() {
+		return nil
+	}
+	minutes := math.Round(s.DayLength.Minutes())
+	return &Sun{
+		Sunrise:          optionalTime(s.Sunrise),
+		Sunset:           optionalTime(s.Sunset),
+		SolarNoon:        optionalTime(s.SolarNoon),
+		CivilDawn:        optionalTime(s.CivilDawn),
+		CivilDusk:        optionalTime(s.CivilDusk),
+		NauticalDawn:     optionalTime(s.NauticalDawn),
+		NauticalDusk:     optionalTime(s.NauticalDusk),
+		AstronomicalDawn: optionalTime(s.AstronomicalDawn),
+		AstronomicalDusk: optionalTime(s.AstronomicalDusk),
+		DayLength:        Quantity{Value: &minutes, Unit: "min"},
+	}
+}
+
+// NewMoon creates a Moon from the MoonTimes of a day
+// It returns nil for the zero MoonTimes, e.g. of daytime forecast periods
+func NewMoon(m astro.MoonTimes) *Moon {
+	if m.Phase.Name == "" {
+		return nil
+	}
+	illumination := math.Round(m.Phase.Illumination * 100)
+	return &Moon{
+		Moonrise:     optionalTime(m.Moonrise),
+		Moonset:      optionalTime(m.Moonset),
+		AlwaysUp:     m.AlwaysUp,
+		AlwaysDown:   m.AlwaysDown,
+		Phase:        m.Phase.Name,
+		Illumination: Quantity{Value: &illumination, Unit: "%"},
+	}
+}
+
+// optionalTime returns a pointer to t, or nil if t is the zero time
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,45 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/models"
+)
+
+// DiscussionSection is a section of an Area Forecast Discussion
+// Key identifies the section, e.g. synopsis, near_term, short_term,
+// long_term or aviation
+// Period is the span of time it covers, if given
+type DiscussionSection struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Period string `json:"period,omitempty"`
+	Text   string `json:"text"`
+}
+
+// Discussion is the body of a /api/discussion response
+type Discussion struct {
+	Location Location            `json:"location"`
+	Office   string              `json:"office"`
+	ID       string              `json:"id"`
+	IssuedAt time.Time           `json:"issuedAt"`
+	Sections []DiscussionSection `json:"sections"`
+}
+
+// NewDiscussion creates a Discussion from a stored location and the
+// latest Area Forecast Discussion of the office covering it, split
+// into its sections
+func NewDiscussion(l models.Location, office string, p apis.Product, sections []apis.DiscussionSection) Discussion {
+	resp := Discussion{
+		Location: NewLocation(l),
+		Office:   office,
+		ID:       p.ID,
+		IssuedAt: p.IssuanceTime,
+		Sections: make([]DiscussionSection, 0, len(sections)),
+	}
+	for _, s := range sections {
+		resp.Sections = append(resp.Sections, DiscussionSection{Key: s.Key, Name: s.Name, Period: s.Period, Text: s.Text})
+	}
+	return resp
+}
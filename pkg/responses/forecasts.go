@@ -0,0 +1,122 @@
+package responses
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/models"
+)
+
+// Location is the location a forecast is for
+// Name is the canonical "City, ST" name of the location, or for bare
+// coordinates the nearest city to them (e.g. "near Ely, MN"), if known
+// City and State are omitted for bare coordinates
+type Location struct {
+	Name  string  `json:"name,omitempty"`
+	City  string  `json:"city,omitempty"`
+	State string  `json:"state,omitempty"`
+	Lat   float64 `json:"lat"`
+	Lng   float64 `json:"lng"`
+}
+
+// Temperature is the temperature over a forecast period
+// Trend is "rising" or "falling" when the temperature is not expected to
+// follow its usual daily pattern
+type Temperature struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+	Trend string  `json:"trend,omitempty"`
+}
+
+// Wind is the wind over a forecast period
+// Speed is a range when it is expected to vary (e.g. "5 to 10 mph")
+type Wind struct {
+	Speed     string `json:"speed"`
+	Direction string `json:"direction"`
+}
+
+// Period is a single period of a forecast
+type Period struct {
+	Name             string      `json:"name,omitempty"`
+	StartTime        time.Time   `json:"startTime"`
+	EndTime          time.Time   `json:"endTime"`
+	IsDaytime        bool        `json:"isDaytime"`
+	Temperature      Temperature `json:"temperature"`
+	Wind             Wind        `json:"wind"`
+	ShortForecast    string      `json:"shortForecast"`
+	DetailedForecast string      `json:"detailedForecast,omitempty"`
+	Icon             string      `json:"icon"`
+}
+
+// DetailedForecast is the body of a /api/v2/forecast/detailed response
+// Sun is the times of the solar events of the day the period starts on,
+// and Moon the times of its lunar events, for night periods only
+type DetailedForecast struct {
+	Location    Location  `json:"location"`
+	Period      Period    `json:"period"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Stale       bool      `json:"stale,omitempty"`
+	Sun         *Sun      `json:"sun,omitempty"`
+	Moon        *Moon     `json:"moon,omitempty"`
+}
+
+// HourlyForecast is the body of a /api/v2/forecast/hourly response
+// Hours is the number of hours requested; there are fewer Periods when
+// weather.gov has fewer left
+type HourlyForecast struct {
+	Location    Location  `json:"location"`
+	Hours       int       `json:"hours"`
+	Periods     []Period  `json:"periods"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Stale       bool      `json:"stale,omitempty"`
+}
+
+// WeekForecast is the body of a /api/forecast/week response
+type WeekForecast struct {
+	Location    Location  `json:"location"`
+	Periods     []Period  `json:"periods"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Stale       bool      `json:"stale,omitempty"`
+}
+
+// NewLocation creates a Location from a stored location
+func NewLocation(l models.Location) Location {
+	loc := Location{
+		City:  l.City,
+		State: l.State,
+		Lat:   l.Lat,
+		Lng:   l.Lng,
+	}
+	if l.City != "" {
+		loc.Name = fmt.Sprintf("%s, %s", l.City, l.State)
+	} else {
+		loc.Name = l.Label
+	}
+	return loc
+}
+
+// NewPeriod creates a Period from a weather.gov forecast period
+func NewPeriod(p apis.ForecastPeriod) Period {
+	startTime, _ := time.Parse(time.RFC3339, p.StartTime)
+	endTime, _ := time.Parse(time.RFC3339, p.EndTime)
+	trend, _ := p.TemperatureTrend.(string)
+	return Period{
+		Name:      p.Name,
+		StartTime: startTime,
+		EndTime:   endTime,
+		IsDaytime: p.IsDaytime,
+		Temperature: Temperature{
+			Value: p.Temperature,
+			Unit:  p.TemperatureUnit,
+			Trend: trend,
+		},
+		Wind: Wind{
+			Speed:     p.WindSpeed,
+			Direction: p.WindDirection,
+		},
+		ShortForecast:    p.ShortForecast,
+		DetailedForecast: p.DetailedForecast,
+		Icon:             p.Icon,
+	}
+}
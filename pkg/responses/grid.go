@@ -0,0 +1,88 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+// GridValue is the value of a GridSeries for the hour starting at Time
+// Value is null where weather.gov has no value
+type GridValue struct {
+	Time  time.Time `json:"time"`
+	Value *float64  `json:"value"`
+}
+
+// GridSeries is an hourly time series of a forecast quantity
+type GridSeries struct {
+	Unit   string      `json:"unit"`
+	Values []GridValue `json:"values"`
+}
+
+// GridForecast is the body of a /api/forecast/grid response
+// QuantitativePrecipitation and SnowfallAmount are amounts per hour
+type GridForecast struct {
+	Location                   Location   `json:"location"`
+	UpdatedAt                  time.Time  `json:"updatedAt"`
+	Temperature                GridSeries `json:"temperature"`
+	Dewpoint                   GridSeries `json:"dewpoint"`
+	RelativeHumidity           GridSeries `json:"relativeHumidity"`
+	SkyCover                   GridSeries `json:"skyCover"`
+	ProbabilityOfPrecipitation GridSeries `json:"probabilityOfPrecipitation"`
+	QuantitativePrecipitation  GridSeries `json:"quantitativePrecipitation"`
+	SnowfallAmount             GridSeries `json:"snowfallAmount"`
+	WindGust                   GridSeries `json:"windGust"`
+}
+
+// NewGridSeries expands a weather.gov grid layer into a GridSeries in the
+// given system of units
+func NewGridSeries(gl apis.GridLayer, accumulated bool, system units.System) (GridSeries, error) {
+	hourly, err := gl.Hourly(accumulated)
+	if err != nil {
+		return GridSeries{}, err
+	}
+	_, unit := units.Convert(0, gl.Uom, system)
+	series := GridSeries{Unit: unit, Values: make([]GridValue, 0, len(hourly))}
+	for _, v := range hourly {
+		value := v.Value
+		if value != nil {
+			converted, _ := units.Convert(*value, gl.Uom, system)
+			value = &converted
+		}
+		series.Values = append(series.Values, GridValue{Time: v.Time, Value: value})
+	}
+	return series, nil
+}
+
+// NewGridForecast creates a GridForecast from a stored location and
+// the weather.gov grid data of its forecast, in the given system of units
+func NewGridForecast(l models.Location, g apis.GridData, system units.System) (GridForecast, error) {
+	resp := GridForecast{
+		Location:  NewLocation(l),
+		UpdatedAt: g.Properties.UpdateTime,
+	}
+	layers := []struct {
+		series      *GridSeries
+		layer       apis.GridLayer
+		accumulated bool
+	}{
+		{&resp.Temperature, g.Properties.Temperature, false},
+		{&resp.Dewpoint, g.Properties.Dewpoint, false},
+		{&resp.RelativeHumidity, g.Properties.RelativeHumidity, false},
+		{&resp.SkyCover, g.Properties.SkyCover, false},
+		{&resp.ProbabilityOfPrecipitation, g.Properties.ProbabilityOfPrecipitation, false},
+		{&resp.QuantitativePrecipitation, g.Properties.QuantitativePrecipitation, true},
+		{&resp.SnowfallAmount, g.Properties.SnowfallAmount, true},
+		{&resp.WindGust, g.Properties.WindGust, false},
+	}
+	for _, l := range layers {
+		series, err := NewGridSeries(l.layer, l.accumulated, system)
+		if err != nil {
+			return GridForecast{}, err
+		}
+		*l.series = series
+	}
+	return resp, nil
+}
@@ -0,0 +1,73 @@
+package responses
+
+import (
+	"path"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+// RelativeLocation is the nearest city to a location, as reported by
+// weather.gov
+// Bearing is the direction of the location from the city
+type RelativeLocation struct {
+	City     string   `json:"city"`
+	State    string   `json:"state"`
+	Distance Quantity `json:"distance"`
+	Bearing  Quantity `json:"bearing"`
+}
+
+// LocationMetadata is the body of a /api/location response
+// Office is the NWS forecast office covering the location, and GridX and
+// GridY its grid coordinates within the office's grid
+// County, ForecastZone and FireWeatherZone are NWS zone ids
+type LocationMetadata struct {
+	Location         Location         `json:"location"`
+	Office           string           `json:"office"`
+	GridX            int              `json:"gridX"`
+	GridY            int              `json:"gridY"`
+	TimeZone         string           `json:"timeZone"`
+	County           string           `json:"county"`
+	ForecastZone     string           `json:"forecastZone"`
+	FireWeatherZone  string           `json:"fireWeatherZone"`
+	RadarStation     string           `json:"radarStation"`
+	RelativeLocation RelativeLocation `json:"relativeLocation"`
+}
+
+// NewLocationMetadata creates a LocationMetadata from a stored location
+// and the weather.gov Points of its grid point, in the given system of units
+func NewLocationMetadata(l models.Location, p apis.Points, system units.System) LocationMetadata {
+	props := p.Properties
+	relative := props.RelativeLocation.Properties
+	return LocationMetadata{
+		Location:        NewLocation(l),
+		Office:          props.Cwa,
+		GridX:           props.GridX,
+		GridY:           props.GridY,
+		TimeZone:        props.TimeZone,
+		County:          zoneID(props.County),
+		ForecastZone:    zoneID(props.ForecastZone),
+		FireWeatherZone: zoneID(props.FireWeatherZone),
+		RadarStation:    props.RadarStation,
+		RelativeLocation: RelativeLocation{
+			City:  relative.City,
+			State: relative.State,
+			Distance: NewQuantity(
+				apis.Measurement{UnitCode: relative.Distance.UnitCode, Value: &relative.Distance.Value},
+				system),
+			Bearing: NewQuantity(
+				apis.Measurement{UnitCode: relative.Bearing.UnitCode, Value: &relative.Bearing.Value},
+				system),
+		},
+	}
+}
+
+// zoneID returns the id of an NWS zone from its url
+// e.g. ILC031 for https://api.weather.gov/zones/county/ILC031
+func zoneID(zoneURL string) string {
+	if zoneURL == "" {
+		return ""
+	}
+	return path.Base(zoneURL)
+}
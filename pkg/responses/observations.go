@@ -0,0 +1,82 @@
+package responses
+
+import (
+	"math"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+// Quantity is a measured value and its unit
+// Value is null when it was not measured
+type Quantity struct {
+	Value *float64 `json:"value"`
+	Unit  string   `json:"unit"`
+}
+
+// Station is the observation station an observation was made at
+// Distance is its distance from the requested location
+type Station struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Distance Quantity `json:"distance"`
+}
+
+// Observation is the body of a /api/observations/latest response
+type Observation struct {
+	Location      Location  `json:"location"`
+	Station       Station   `json:"station"`
+	Timestamp     time.Time `json:"timestamp"`
+	Description   string    `json:"description"`
+	Temperature   Quantity  `json:"temperature"`
+	Dewpoint      Quantity  `json:"dewpoint"`
+	Humidity      Quantity  `json:"humidity"`
+	WindSpeed     Quantity  `json:"windSpeed"`
+	WindGust      Quantity  `json:"windGust"`
+	WindDirection Quantity  `json:"windDirection"`
+	Pressure      Quantity  `json:"pressure"`
+	Visibility    Quantity  `json:"visibility"`
+}
+
+// NewQuantity converts a weather.gov measurement into a Quantity in the
+// given system of units, rounded to one decimal place
+func NewQuantity(m apis.Measurement, system units.System) Quantity {
+	var value float64
+	if m.Value != nil {
+		value = *m.Value
+	}
+	value, unit := units.Convert(value, m.UnitCode, system)
+	if m.Value == nil {
+		return Quantity{Unit: unit}
+	}
+	value = math.Round(value*10) / 10
+	return Quantity{Value: &value, Unit: unit}
+}
+
+// NewObservation creates an Observation from a stored location and the
+// latest observation of the station chosen for it
+func NewObservation(l models.Location, o cache.CachedObservation, system units.System) Observation {
+	p := o.Observation.Properties
+	distance := o.Distance * 1000
+	return Observation{
+		Location: NewLocation(l),
+		Station: Station{
+			ID:       o.Station.Properties.StationIdentifier,
+			Name:     o.Station.Properties.Name,
+			Distance: NewQuantity(apis.Measurement{UnitCode: "wmoUnit:m", Value: &distance}, system),
+		},
+		Timestamp:     p.Timestamp,
+		Description:   p.TextDescription,
+		Temperature:   NewQuantity(p.Temperature, system),
+		Dewpoint:      NewQuantity(p.Dewpoint, system),
+		Humidity:      NewQuantity(p.RelativeHumidity, system),
+		WindSpeed:     NewQuantity(p.WindSpeed, system),
+		WindGust:      NewQuantity(p.WindGust, system),
+		WindDirection: NewQuantity(p.WindDirection, system),
+		Pressure:      NewQuantity(o.Observation.Pressure(), system),
+		Visibility:    NewQuantity(p.Visibility, system),
+	}
+}
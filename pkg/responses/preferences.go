@@ -0,0 +1,8 @@
+package responses
+
+// Preferences is the body of a /api/preferences response
+// Units is the system of units forecasts are reported in by default
+type Preferences struct {
+	User  string `json:"user"`
+	Units string `json:"units"`
+}
@@ -3,13 +3,94 @@ package responses
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
 )
 
+// Media type of RFC 7807 problem details
+const problemJSON = "application/problem+json"
+
+// Prefix of the type URI of each kind of problem
+// problem types are URNs rather than URLs, as they are not dereferenceable
+const problemTypePrefix = "urn:thorcast:problem:"
+
+// HTTP status code of each kind of error
+var statusCodes = map[errs.Code]int{
+	errs.CodeInvalidState:        http.StatusBadRequest,
+	errs.CodeInvalidPeriod:       http.StatusBadRequest,
+	errs.CodeInvalidHours:        http.StatusBadRequest,
+	errs.CodeInvalidCoordinates:  http.StatusBadRequest,
+	errs.CodeInvalidFormat:       http.StatusBadRequest,
+	errs.CodeInvalidUnits:        http.StatusBadRequest,
+	errs.CodeInvalidUser:         http.StatusBadRequest,
+	errs.CodeInvalidDate:         http.StatusBadRequest,
+	errs.CodeLocationNotFound:    http.StatusNotFound,
+	errs.CodePeriodUnavailable:   http.StatusNotFound,
+	errs.CodeOutOfCoverage:       http.StatusUnprocessableEntity,
+	errs.CodeUpstreamUnavailable: http.StatusServiceUnavailable,
+	errs.CodeUpstreamError:       http.StatusBadGateway,
+	errs.CodeStorageUnavailable:  http.StatusServiceUnavailable,
+	errs.CodeCacheUnavailable:    http.StatusServiceUnavailable,
+	errs.CodeNotFound:            http.StatusNotFound,
+	errs.CodeInternal:            http.StatusInternalServerError,
+}
+
+// Problem is an RFC 7807 problem details object
+// Code and Param are extension members: the machine readable error code
+// and the request parameter at fault
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Param    string `json:"param,omitempty"`
+}
+
+// StatusCode returns the HTTP status code for an error
+// untyped errors are internal server errors
+func StatusCode(err error) int {
+	if code, ok := statusCodes[errs.As(err).Code]; ok {
+		return code
+	}
+	return http.StatusInternalServerError
+}
+
+// NewProblem creates the Problem describing err for the request r
+func NewProblem(r *http.Request, err error) Problem {
+	e := errs.As(err)
+	return Problem{
+		Type:     problemTypePrefix + string(e.Code),
+		Title:    e.Message,
+		Status:   StatusCode(err),
+		Detail:   e.Detail,
+		Instance: r.URL.RequestURI(),
+		Code:     string(e.Code),
+		Param:    e.Param,
+	}
+}
+
+// wantsProblem reports whether the client accepts problem details
+func wantsProblem(r *http.Request) bool {
+	for _, accept := range r.Header["Accept"] {
+		if strings.Contains(accept, problemJSON) {
+			return true
+		}
+	}
+	return false
+}
+
 // RespondWithJSON function to respond with a JSON payload
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	respondWithContentType(w, code, "application/json", payload)
+}
+
+func respondWithContentType(w http.ResponseWriter, code int, contentType string, payload interface{}) {
 	response, _ := json.Marshal(payload)
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(code)
 	_, _ = w.Write(response)
 }
@@ -20,6 +101,22 @@ func RespondWithMessage(w http.ResponseWriter, code int, message string) {
 }
 
 // RespondWithError is a wrapper function for responding on an unsuccessful request
-func RespondWithError(w http.ResponseWriter, code int, message string) {
-	RespondWithJSON(w, code, map[string]string{"error": message})
+// the status code is derived from the kind of error, which is also
+// reported as a machine readable code
+// Clients sending "Accept: application/problem+json" receive RFC 7807
+// problem details; all others receive the legacy {"error", "code"} shape
+func RespondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	if wantsProblem(r) {
+		RespondWithProblem(w, NewProblem(r, err))
+		return
+	}
+	status := StatusCode(err)
+	RespondWithJSON(w, status, map[string]string{
+		"error": http.StatusText(status),
+		"code":  string(errs.As(err).Code)})
+}
+
+// RespondWithProblem responds with RFC 7807 problem details
+func RespondWithProblem(w http.ResponseWriter, p Problem) {
+	respondWithContentType(w, p.Status, problemJSON, p)
 }
@@ -0,0 +1,51 @@
+package responses
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+)
+
+func TestRespondWithErrorLegacy(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/forecast/detailed?city=Gotham&state=NY", nil)
+	w := httptest.NewRecorder()
+
+	RespondWithError(w, r, errs.ErrLocationNotFound)
+
+	var body map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+
+	if w.Code != http.StatusNotFound || body["code"] != "location_not_found" || body["error"] != "Not Found" {
+		t.Errorf("Response was incorrect, got: %d %v", w.Code, body)
+	}
+}
+
+func TestRespondWithErrorProblem(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/forecast/detailed?city=Chicago&state=IL&period=Reindeer", nil)
+	r.Header.Set("Accept", "application/problem+json")
+	w := httptest.NewRecorder()
+
+	RespondWithError(w, r, errs.WithDetail(errs.ErrInvalidPeriod, "Reindeer is not a period."))
+
+	var p Problem
+	_ = json.Unmarshal(w.Body.Bytes(), &p)
+
+	target := Problem{
+		Type:     "urn:thorcast:problem:invalid_period",
+		Title:    "Invalid period.",
+		Status:   http.StatusBadRequest,
+		Detail:   "Reindeer is not a period.",
+		Instance: "/api/forecast/detailed?city=Chicago&state=IL&period=Reindeer",
+		Code:     "invalid_period",
+		Param:    "period"}
+
+	if w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Content-Type was incorrect, got: %s", w.Header().Get("Content-Type"))
+	}
+	if p != target {
+		t.Errorf("Problem was incorrect, got: %v, want: %v", p, target)
+	}
+}
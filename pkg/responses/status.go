@@ -0,0 +1,33 @@
+package responses
+
+import (
+	"github.com/kylep342/thorcast-server/pkg/apis"
+)
+
+// Overall status of thorcast reported by /api/status
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// Status is the body of a /api/status response
+// Upstreams holds the circuit breaker of each upstream host, by host
+type Status struct {
+	Status    string                        `json:"status"`
+	Upstreams map[string]apis.BreakerStatus `json:"upstreams"`
+}
+
+// NewStatus creates a Status from the circuit breakers of upstream hosts
+// thorcast is degraded while any of them is not closed
+func NewStatus(upstreams map[string]apis.BreakerStatus) Status {
+	status := Status{Status: StatusOK, Upstreams: upstreams}
+	if status.Upstreams == nil {
+		status.Upstreams = map[string]apis.BreakerStatus{}
+	}
+	for _, b := range status.Upstreams {
+		if b.State != apis.BreakerClosed.String() {
+			status.Status = StatusDegraded
+		}
+	}
+	return status
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+// LocationAlerts is the alerts in effect at a Location
+// Location is the stored location a city and state resolved to, or just
+// the coordinates alerts were requested for
+type LocationAlerts struct {
+	Location models.Location
+	Alerts   []apis.Alert
+}
+
+// ActiveAlerts returns the alerts in effect for the given city and state
+func (fs *ForecastService) ActiveAlerts(ctx context.Context, city, state string) (LocationAlerts, error) {
+	cleanCity := utils.SanitizeCity(city)
+	cleanState, err := utils.SanitizeState(state)
+	if err != nil {
+		return LocationAlerts{}, err
+	}
+	l, err := fs.resolve(ctx, cleanCity, cleanState)
+	if err != nil {
+		return LocationAlerts{}, err
+	}
+	return fs.activeAlerts(ctx, l)
+}
+
+// ActiveAlertsAt returns the alerts in effect at the given latitude and longitude
+func (fs *ForecastService) ActiveAlertsAt(ctx context.Context, lat, lng string) (LocationAlerts, error) {
+	coordinates, err := utils.SanitizeCoordinates(lat, lng)
+	if err != nil {
+		return LocationAlerts{}, err
+	}
+	var l models.Location
+	l.SetLocationCoordinates(coordinates)
+	return fs.activeAlerts(ctx, l)
+}
+
+// activeAlerts looks up the alerts in effect at a Location in the cache,
+// fetching and caching them from the AlertProvider on a miss
+func (fs *ForecastService) activeAlerts(ctx context.Context, l models.Location) (LocationAlerts, error) {
+	if fs.Alerts == nil {
+		return LocationAlerts{}, errs.Wrap(errs.ErrInternal, errors.New("no alert provider is configured"))
+	}
+	coordinates := models.Coordinates{Lat: l.Lat, Lng: l.Lng}
+	result := LocationAlerts{Location: l}
+	alerts, err := cache.LookupAlerts(fs.Cache, coordinates)
+	if err == nil {
+		result.Alerts = alertsOf(alerts)
+		return result, nil
+	} else if err != cache.ErrCacheMiss {
+		return LocationAlerts{}, err
+	}
+	key := fmt.Sprintf("alerts_%.4f_%.4f", l.Lat, l.Lng)
+	val, err := fs.flight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		alerts, err := fs.Alerts.FetchAlerts(ctx, coordinates)
+		if err != nil {
+			return apis.Alerts{}, err
+		}
+		cache.CacheAlerts(fs.Cache, coordinates, alerts)
+		return alerts, nil
+	})
+	if err != nil {
+		return LocationAlerts{}, err
+	}
+	result.Alerts = alertsOf(val.(apis.Alerts))
+	return result, nil
+}
+
+// alertsOf returns each alert of a weather.gov alerts response
+func alertsOf(alerts apis.Alerts) []apis.Alert {
+	result := make([]apis.Alert, 0, len(alerts.Features))
+	for _, f := range alerts.Features {
+		result = append(result, f.Properties)
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/astro"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+// Astronomy is the times of the solar and lunar events of a day at a City
+// and State
+// Location is the stored location the City and State resolved to, and
+// Date the midnight starting the day in the location's time zone
+type Astronomy struct {
+	City     utils.City
+	State    utils.State
+	Location models.Location
+	Date     time.Time
+	Sun      astro.SunTimes
+	Moon     astro.MoonTimes
+}
+
+// Astro returns the times of sunrise, sunset, solar noon, twilight,
+// moonrise and moonset and the phase of the Moon at the given city and
+// state on date (YYYY-MM-DD), today if empty
+// They are computed locally, in the time zone of the location's grid
+// point, which is fetched from weather.gov only if not already stored
+func (fs *ForecastService) Astro(ctx context.Context, city, state, date string) (Astronomy, error) {
+	cleanCity := utils.SanitizeCity(city)
+	cleanState, err := utils.SanitizeState(state)
+	if err != nil {
+		return Astronomy{}, err
+	}
+	l, err := fs.resolve(ctx, cleanCity, cleanState)
+	if err != nil {
+		return Astronomy{}, err
+	}
+	return fs.astronomy(ctx, cleanCity, cleanState, l, date)
+}
+
+// AstroAt returns the times of the solar and lunar events and the phase of
+// the Moon at the given latitude and longitude on date (YYYY-MM-DD), today
+// if empty
+func (fs *ForecastService) AstroAt(ctx context.Context, lat, lng, date string) (Astronomy, error) {
+	city, state, l, err := fs.locate(ctx, lat, lng)
+	if err != nil {
+		return Astronomy{}, err
+	}
+	return fs.astronomy(ctx, city, state, l, date)
+}
+
+// astronomy computes the Astronomy of a Location on date, in its time zone
+func (fs *ForecastService) astronomy(
+	ctx context.Context,
+	city utils.City,
+	state utils.State,
+	l models.Location,
+	date string,
+) (Astronomy, error) {
+	loc := fs.timeZone(ctx, city, state, l)
+	y, m, d := time.Now().In(loc).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if date != "" {
+		requested, err := utils.SanitizeDate(date, loc)
+		if err != nil {
+			return Astronomy{}, err
+		}
+		day = requested
+	}
+	return Astronomy{
+		City:     city,
+		State:    state,
+		Location: l,
+		Date:     day,
+		Sun:      astro.Sun(day, l.Lat, l.Lng),
+		Moon:     astro.Moon(day, l.Lat, l.Lng),
+	}, nil
+}
+
+// timeZone returns the time zone of a stored location, from its grid
+// point, fetching its Points (but no forecast) if it is not stored
+// If they cannot be fetched, it falls back to the zone of zoneOf
+func (fs *ForecastService) timeZone(
+	ctx context.Context,
+	city utils.City,
+	state utils.State,
+	l models.Location,
+) *time.Location {
+	_, located, err := fs.gridCell(ctx, city, state, l)
+	// a cached grid cell spares the fetch, but has no time zone
+	if err == nil && !located.HasGridPoint() {
+		var p apis.Points
+		if p, _, err = fs.points(ctx, l, false); err == nil {
+			located.GridPoint = p.GridPoint(time.Now().UTC())
+		}
+	}
+	if err != nil {
+		log.Printf("Error finding the time zone of %s\nError is: %s\n", l.Name(), err.Error())
+		return zoneOf(l)
+	}
+	return zoneOf(located)
+}
+
+// zoneOf returns the time zone of a location, as stored with its grid
+// point
+// Locations whose grid point is not known (or whose time zone is not in
+// the tz database) fall back to the mean solar time zone of their
+// longitude, which ignores daylight saving time
+func zoneOf(l models.Location) *time.Location {
+	if l.GridPoint.TimeZone != "" {
+		loc, err := time.LoadLocation(l.GridPoint.TimeZone)
+		if err == nil {
+			return loc
+		}
+		log.Printf("Error loading time zone %s\nError is %s\n", l.GridPoint.TimeZone, err.Error())
+	}
+	offset := int(math.Round(l.Lng / 15))
+	return time.FixedZone(fmt.Sprintf("UTC%+d", offset), offset*3600)
+}
+
+// withAstronomy sets the SunTimes of the day its period starts on at its
+// location on a DetailedForecast, in the time zone of the period, along
+// with the MoonTimes of night periods, from their start to their end
+func withAstronomy(result DetailedForecast) DetailedForecast {
+	start, err := time.Parse(time.RFC3339, result.Forecast.StartTime)
+	if err != nil {
+		return result
+	}
+	l := result.Location
+	result.Sun = astro.Sun(start, l.Lat, l.Lng)
+	if result.Forecast.IsDaytime {
+		return result
+	}
+	end, err := time.Parse(time.RFC3339, result.Forecast.EndTime)
+	if err != nil {
+		result.Moon = astro.Moon(start, l.Lat, l.Lng)
+		return result
+	}
+	result.Moon = astro.MoonBetween(start, end, l.Lat, l.Lng)
+	return result
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/models"
+)
+
+func TestWithAstronomySpansTheNight(t *testing.T) {
+	result := withAstronomy(DetailedForecast{
+		Location: models.Location{Lat: 50.5, Lng: 30.5},
+		Forecast: cache.CachedPeriod{ForecastPeriod: apis.ForecastPeriod{
+			StartTime: "2013-03-04T20:00:00Z",
+			EndTime:   "2013-03-05T12:00:00Z",
+			IsDaytime: false}},
+	})
+
+	// the Moon rises on the evening the night starts and sets the next morning
+	if result.Moon.Moonrise.Day() != 4 || result.Moon.Moonset.Day() != 5 {
+		t.Errorf("Moon was incorrect, got: rise %s, set %s", result.Moon.Moonrise, result.Moon.Moonset)
+	}
+	if result.Sun.Sunset.Day() != 4 {
+		t.Errorf("Sunset was incorrect, got: %s, want: on March 4th", result.Sun.Sunset)
+	}
+	if daytime := withAstronomy(DetailedForecast{Forecast: cache.CachedPeriod{ForecastPeriod: apis.ForecastPeriod{StartTime: "2013-03-04T06:00:00Z", IsDaytime: true}}}); daytime.Moon.Phase.Name != "" {
+		t.Errorf("Moon was incorrect for a daytime period, got: %+v", daytime.Moon)
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+// DetailedAt returns the detailed forecast for the given latitude,
+// longitude, and period in the given System of units
+// an empty period defaults to today
+func (fs *ForecastService) DetailedAt(ctx context.Context, lat, lng, period string, system units.System) (DetailedForecast, error) {
+	if period == "" {
+		period = defaultPeriod
+	}
+	cleanPeriod, err := utils.SanitizePeriod(period)
+	if err != nil {
+		return DetailedForecast{}, err
+	}
+	city, state, l, err := fs.locate(ctx, lat, lng)
+	if err != nil {
+		return DetailedForecast{}, err
+	}
+	return fs.detailed(ctx, city, state, cleanPeriod, l, system)
+}
+
+// HourlyAt returns the next hours hourly forecasts for the given latitude
+// and longitude in the given System of units
+// an empty hours defaults to 12
+func (fs *ForecastService) HourlyAt(ctx context.Context, lat, lng, hours string, system units.System) (HourlyForecast, error) {
+	if hours == "" {
+		hours = defaultHours
+	}
+	cleanHours, err := utils.SanitizeHours(hours)
+	if err != nil {
+		return HourlyForecast{}, err
+	}
+	city, state, l, err := fs.locate(ctx, lat, lng)
+	if err != nil {
+		return HourlyForecast{}, err
+	}
+	return fs.hourly(ctx, city, state, cleanHours, l, system)
+}
+
+// WeekAt returns every available period of the detailed forecast for the
+// given latitude and longitude, in order, in the given System of units
+func (fs *ForecastService) WeekAt(ctx context.Context, lat, lng string, system units.System) (WeekForecast, error) {
+	city, state, l, err := fs.locate(ctx, lat, lng)
+	if err != nil {
+		return WeekForecast{}, err
+	}
+	return fs.week(ctx, city, state, l, system)
+}
+
+// GridAt returns the raw grid data of the forecast for the given latitude
+// and longitude
+func (fs *ForecastService) GridAt(ctx context.Context, lat, lng string) (GridForecast, error) {
+	city, state, l, err := fs.locate(ctx, lat, lng)
+	if err != nil {
+		return GridForecast{}, err
+	}
+	return fs.grid(ctx, city, state, l)
+}
+
+// locate returns the Location of the given latitude and longitude, along
+// with the City and State weather.gov reports nearest to them, see
+// LocationAt
+func (fs *ForecastService) locate(ctx context.Context, lat, lng string) (utils.City, utils.State, models.Location, error) {
+	result, err := fs.LocationAt(ctx, lat, lng)
+	if err != nil {
+		return utils.City{}, utils.State{}, models.Location{}, err
+	}
+	return result.City, result.State, result.Location, nil
+}
+
+// pointsAt fetches the Points of a Location requested by coordinates and
+// caches them; concurrent calls for the same coordinates share the fetch
+func (fs *ForecastService) pointsAt(ctx context.Context, l models.Location) (apis.Points, error) {
+	coordinates := models.Coordinates{Lat: l.Lat, Lng: l.Lng}
+	key := fmt.Sprintf("points_%.4f_%.4f", l.Lat, l.Lng)
+	val, err := fs.flight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		p, err := fs.Forecasts.FetchPoints(ctx, l)
+		if err != nil {
+			return apis.Points{}, err
+		}
+		cache.CachePointsAt(fs.Cache, coordinates, p)
+		return p, nil
+	})
+	if err != nil {
+		return apis.Points{}, err
+	}
+	return val.(apis.Points), nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/db"
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+func TestDetailedAtBypassesGeocoding(t *testing.T) {
+	fs, provider := newTestService(t)
+	c := models.Coordinates{Lat: 41.8781, Lng: -87.6298}
+	points := provider.Points[c]
+	points.Properties.RelativeLocation.Properties.City = "Chicago"
+	points.Properties.RelativeLocation.Properties.State = "IL"
+	provider.Points[c] = points
+
+	result, err := fs.DetailedAt(context.Background(), "41.87812", "-87.62978", "", units.US)
+	if err != nil || result.Forecast.DetailedForecast != "Sunny, with a high near 75." {
+		t.Fatalf("Forecast was incorrect, got: %q (%v)", result.Forecast.DetailedForecast, err)
+	}
+	if result.Location.Label != "near Chicago, IL" || result.City.Name() != "Chicago" || result.State.Name() != "IL" {
+		t.Errorf("Label was incorrect, got: %q (%s, %s), want: near Chicago, IL", result.Location.Label, result.City.Name(), result.State.Name())
+	}
+
+	if _, err := fs.Locations.Lookup("Chicago", "IL"); !errors.Is(err, db.ErrLocationNotFound) {
+		t.Errorf("Location was stored, got: %v, want: %v", err, db.ErrLocationNotFound)
+	}
+
+	// the points of the coordinates are cached
+	delete(provider.Points, c)
+	if _, err := fs.WeekAt(context.Background(), "41.8781", "-87.6298", units.US); err != nil {
+		t.Errorf("Points were not cached, got: %v", err)
+	}
+}
+
+func TestDetailedAtInvalidCoordinates(t *testing.T) {
+	fs, _ := newTestService(t)
+
+	if _, err := fs.DetailedAt(context.Background(), "91", "-87.6298", "", units.US); !errors.Is(err, errs.ErrInvalidCoordinates) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrInvalidCoordinates)
+	}
+}
+
+func TestLatestObservationAtIsCachedByCoordinates(t *testing.T) {
+	fs, provider := newTestService(t)
+	c := models.Coordinates{Lat: 41.8781, Lng: -87.6298}
+	points := provider.Points[c]
+	points.Properties.RelativeLocation.Properties.City = "Chicago"
+	points.Properties.RelativeLocation.Properties.State = "IL"
+	provider.Points[c] = points
+	provider.Stations[points.Properties.ObservationStations] = apis.Stations{Features: []apis.Station{
+		newTestStation("KMDW", 41.7841, -87.7551),
+	}}
+	temperature := 22.0
+	provider.Observations["KMDW"] = newTestObservation("Clear", &temperature)
+
+	result, err := fs.LatestObservationAt(context.Background(), "41.8781", "-87.6298")
+	if err != nil || result.Observation.Station.Properties.StationIdentifier != "KMDW" || result.Location.Label != "near Chicago, IL" {
+		t.Fatalf("Observation was incorrect, got: %s at %q (%v)", result.Observation.Station.Properties.StationIdentifier, result.Location.Label, err)
+	}
+
+	// it must not be served to requests for the nearest city
+	if _, err := cache.LookupObservation(fs.Cache, result.City, result.State); err != cache.ErrCacheMiss {
+		t.Errorf("Observation was cached by city, got: %v", err)
+	}
+	if _, err := cache.LookupObservationAt(fs.Cache, c); err != nil {
+		t.Errorf("Observation was not cached by coordinates, got: %v", err)
+	}
+}
+
+func TestLocationAtOutsideTheStates(t *testing.T) {
+	fs, provider := newTestService(t)
+	c := models.Coordinates{Lat: 41.8781, Lng: -87.6298}
+	points := provider.Points[c]
+	points.Properties.RelativeLocation.Properties.City = "San Juan"
+	points.Properties.RelativeLocation.Properties.State = "PR"
+	provider.Points[c] = points
+
+	result, err := fs.LocationAt(context.Background(), "41.8781", "-87.6298")
+	if err != nil || result.Location.Label != "near San Juan, PR" {
+		t.Fatalf("Label was incorrect, got: %q (%v), want: near San Juan, PR", result.Location.Label, err)
+	}
+	if result.City.Name() != "" || result.State.Name() != "" {
+		t.Errorf("City and State were incorrect, got: %q, %q, want: none", result.City.Name(), result.State.Name())
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+// AreaDiscussion is the latest Area Forecast Discussion of the forecast
+// office covering a City and State
+// Location is the stored location the City and State resolved to
+// Sections is the Discussion split into its sections, in order
+type AreaDiscussion struct {
+	City       utils.City
+	State      utils.State
+	Location   models.Location
+	Office     string
+	Discussion apis.Product
+	Sections   []apis.DiscussionSection
+}
+
+// Discussion returns the latest Area Forecast Discussion of the forecast
+// office covering the given city and state
+// Discussions are cached by office until its next one is expected
+func (fs *ForecastService) Discussion(ctx context.Context, city, state string) (AreaDiscussion, error) {
+	cleanCity := utils.SanitizeCity(city)
+	cleanState, err := utils.SanitizeState(state)
+	if err != nil {
+		return AreaDiscussion{}, err
+	}
+	l, err := fs.resolve(ctx, cleanCity, cleanState)
+	if err != nil {
+		return AreaDiscussion{}, err
+	}
+	return fs.discussion(ctx, cleanCity, cleanState, l)
+}
+
+// DiscussionAt returns the latest Area Forecast Discussion of the forecast
+// office covering the given latitude and longitude
+func (fs *ForecastService) DiscussionAt(ctx context.Context, lat, lng string) (AreaDiscussion, error) {
+	city, state, l, err := fs.locate(ctx, lat, lng)
+	if err != nil {
+		return AreaDiscussion{}, err
+	}
+	return fs.discussion(ctx, city, state, l)
+}
+
+// discussion looks up the latest Area Forecast Discussion of the forecast
+// office covering a Location in the cache, fetching and caching it on a
+// miss
+func (fs *ForecastService) discussion(
+	ctx context.Context,
+	city utils.City,
+	state utils.State,
+	l models.Location,
+) (AreaDiscussion, error) {
+	if fs.Discussions == nil {
+		return AreaDiscussion{}, errs.Wrap(errs.ErrInternal, errors.New("no discussion provider is configured"))
+	}
+	cell, l, err := fs.gridCell(ctx, city, state, l)
+	if err != nil {
+		return AreaDiscussion{}, err
+	}
+	result := AreaDiscussion{City: city, State: state, Location: l, Office: cell.Office}
+	p, err := cache.LookupDiscussion(fs.Cache, cell.Office)
+	if err == nil {
+		result.Discussion = p
+		result.Sections = apis.ParseDiscussion(p.ProductText)
+		return result, nil
+	} else if err != cache.ErrCacheMiss {
+		return AreaDiscussion{}, err
+	}
+	key := fmt.Sprintf("%s_discussion", cell.Office)
+	val, err := fs.flight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		p, err := fs.Discussions.FetchDiscussion(ctx, cell.Office)
+		if err != nil {
+			return apis.Product{}, err
+		}
+		cache.CacheDiscussion(fs.Cache, cell.Office, p)
+		return p, nil
+	})
+	if err != nil {
+		return AreaDiscussion{}, err
+	}
+	result.Discussion = val.(apis.Product)
+	result.Sections = apis.ParseDiscussion(result.Discussion.ProductText)
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+)
+
+func TestDiscussionIsCachedByOffice(t *testing.T) {
+	fs, provider := newTestService(t)
+	provider.Discussions["TST"] = apis.Product{
+		ID:           "afd-1",
+		IssuanceTime: time.Now().UTC(),
+		ProductText:  ".SYNOPSIS...\nShowers.\n&&\n.LONG TERM...\nDry.\n&&"}
+
+	result, err := fs.Discussion(context.Background(), "Chicago", "IL")
+	if err != nil || result.Office != "TST" || len(result.Sections) != 2 || result.Sections[1].Key != "long_term" {
+		t.Fatalf("Discussion was incorrect, got: %+v (%v)", result.Sections, err)
+	}
+
+	// a cache hit must not need the provider
+	delete(provider.Discussions, "TST")
+	if result, err = fs.Discussion(context.Background(), "Chicago", "IL"); err != nil || result.Discussion.ID != "afd-1" {
+		t.Errorf("Discussion was not cached, got: %q (%v)", result.Discussion.ID, err)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+)
+
+// How long a shared upstream fetch may run: long enough for a few
+// requests (e.g. points, then a forecast) each with the client's retries,
+// but no longer than a caller would plausibly wait
+const flightTimeout = 45 * time.Second
+
+// call is an upstream fetch shared by concurrent callers
+// waiters is the number of callers still waiting on its result
+type call struct {
+	done    chan struct{}
+	val     interface{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// flightGroup coalesces concurrent calls for the same key into a single
+// upstream fetch
+// Unlike singleflight, the fetch runs under a context of its own rather
+// than any one caller's, so a caller giving up does not fail the others,
+// but it is cancelled once every caller has given up, and bounded by
+// flightTimeout
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do calls fetch once for concurrent callers with the same key, and
+// returns its result
+// If ctx is done first the caller stops waiting, with
+// errs.ErrUpstreamUnavailable; the last caller to do so cancels the fetch
+func (g *flightGroup) Do(
+	ctx context.Context,
+	key string,
+	fetch func(ctx context.Context) (interface{}, error),
+) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		fetchCtx, cancel := context.WithTimeout(context.Background(), flightTimeout)
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(fetchCtx, key, c, fetch)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			g.forget(key, c)
+		}
+		g.mu.Unlock()
+		return nil, errs.Wrap(errs.ErrUpstreamUnavailable, ctx.Err())
+	}
+}
+
+// run runs the fetch of a call and publishes its result
+func (g *flightGroup) run(
+	ctx context.Context,
+	key string,
+	c *call,
+	fetch func(ctx context.Context) (interface{}, error),
+) {
+	c.val, c.err = fetch(ctx)
+	c.cancel()
+	g.mu.Lock()
+	g.forget(key, c)
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// forget removes a call from the group, so later callers start a fetch of
+// their own, unless it was already replaced by a newer call
+// g.mu must be held
+func (g *flightGroup) forget(key string, c *call) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
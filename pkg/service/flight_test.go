@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+)
+
+func TestFlightIsSharedByConcurrentCallers(t *testing.T) {
+	var g flightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetches := 0
+	fetch := func(ctx context.Context) (interface{}, error) {
+		fetches++
+		close(started)
+		<-release
+		return "forecast", nil
+	}
+
+	results := make(chan interface{}, 2)
+	go func() {
+		val, _ := g.Do(context.Background(), "key", fetch)
+		results <- val
+	}()
+	<-started
+	go func() {
+		val, _ := g.Do(context.Background(), "key", fetch)
+		results <- val
+	}()
+	// let the second caller join the flight before it lands
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		if val := <-results; val != "forecast" {
+			t.Errorf("Result was incorrect, got: %v, want: forecast", val)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Fetches were incorrect, got: %d, want: 1", fetches)
+	}
+}
+
+func TestFlightIsCancelledOnceEveryCallerGivesUp(t *testing.T) {
+	var g flightGroup
+	cancelled := make(chan struct{})
+	fetch := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+	errors1 := make(chan error, 1)
+	errors2 := make(chan error, 1)
+	go func() {
+		_, err := g.Do(first, "key", fetch)
+		errors1 <- err
+	}()
+	go func() {
+		_, err := g.Do(second, "key", fetch)
+		errors2 <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancelFirst()
+	if err := <-errors1; !errors.Is(err, errs.ErrUpstreamUnavailable) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrUpstreamUnavailable)
+	}
+	select {
+	case <-cancelled:
+		t.Fatal("Fetch was cancelled while a caller was still waiting")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancelSecond()
+	<-errors2
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Fetch was not cancelled after every caller gave up")
+	}
+}
@@ -0,0 +1,620 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/astro"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/db"
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+// Default number of hours of an hourly forecast
+const defaultHours = "12"
+
+// Default period of a detailed forecast
+const defaultPeriod = "today"
+
+// How long the stored grid point of a location is used before it is
+// fetched again
+// A moved grid point is already caught by the refresh on a 404 from one
+// of its urls, so this only picks up metadata which changes without
+// breaking them (time zone, forecast zone, radar station); a month keeps
+// that reasonably current at one /points call per location per month
+const gridPointRefresh = 30 * 24 * time.Hour
+
+// Forecast products fetched from a ForecastProvider
+const (
+	productDetailed = "detailed"
+	productHourly   = "hourly"
+	productGrid     = "grid"
+	productObserved = "observation"
+)
+
+// ForecastService retrieves forecasts for a location, shared by every
+// frontend (HTTP handlers, chat integrations, a CLI)
+// Each lookup resolves the location from the LocationStore (geocoding and
+// registering it if unknown), then checks the cache for its weather.gov
+// grid cell, then fetches and caches forecasts from the ForecastProvider
+// Forecasts are cached by grid cell, so nearby locations share them
+// Failures are returned as errs.Error, so callers can report them
+// consistently
+// Concurrent cache misses for the same location and product share a
+// single upstream fetch
+// While the ForecastProvider is unavailable, the last forecasts fetched
+// for a location are served instead, marked Stale
+// Alerts is the source of weather alerts, Observations the source of
+// current conditions and Discussions the source of forecasters' reasoning
+// Preferences stores the System of units each user prefers
+type ForecastService struct {
+	Cache        cache.ForecastCache
+	Locations    db.LocationStore
+	Geocoder     apis.Geocoder
+	Forecasts    apis.ForecastProvider
+	Alerts       apis.AlertProvider
+	Observations apis.ObservationProvider
+	Discussions  apis.DiscussionProvider
+	Preferences  db.PreferenceStore
+
+	flight flightGroup
+}
+
+// NewForecastService creates a ForecastService from its dependencies
+// Alerts, observations and discussions are fetched from the
+// ForecastProvider when it is also an AlertProvider, ObservationProvider
+// and DiscussionProvider, as weather.gov is
+// Preferences are stored in the LocationStore when it is also a
+// PreferenceStore, as both database backed stores are
+func NewForecastService(
+	c cache.ForecastCache,
+	locations db.LocationStore,
+	geocoder apis.Geocoder,
+	forecasts apis.ForecastProvider,
+) *ForecastService {
+	alerts, _ := forecasts.(apis.AlertProvider)
+	observations, _ := forecasts.(apis.ObservationProvider)
+	discussions, _ := forecasts.(apis.DiscussionProvider)
+	preferences, _ := locations.(db.PreferenceStore)
+	return &ForecastService{
+		Cache:        c,
+		Locations:    locations,
+		Geocoder:     geocoder,
+		Forecasts:    forecasts,
+		Alerts:       alerts,
+		Observations: observations,
+		Discussions:  discussions,
+		Preferences:  preferences,
+	}
+}
+
+// DetailedForecast is the detailed forecast for a City, State, and Period
+// Location is the stored location the City and State resolved to
+// Stale reports whether the forecast was served from the last fetched
+// forecasts because the ForecastProvider is unavailable
+// Sun is the times of the solar events of the day the period starts on,
+// and Moon the times of its lunar events, for night periods only
+type DetailedForecast struct {
+	City     utils.City
+	State    utils.State
+	Period   utils.Period
+	Location models.Location
+	Forecast cache.CachedPeriod
+	Stale    bool
+	Sun      astro.SunTimes
+	Moon     astro.MoonTimes
+}
+
+// HourlyForecast is the next Hours hourly forecasts for a City and State
+// Location is the stored location the City and State resolved to
+// Stale reports whether the forecasts were served from the last fetched
+// forecasts because the ForecastProvider is unavailable
+type HourlyForecast struct {
+	City      utils.City
+	State     utils.State
+	Hours     int64
+	Location  models.Location
+	Forecasts []cache.CachedPeriod
+	Stale     bool
+}
+
+// WeekForecast is every period of the detailed forecast for a City and
+// State, in order
+// Location is the stored location the City and State resolved to
+// Stale reports whether the forecasts were served from the last fetched
+// forecasts because the ForecastProvider is unavailable
+type WeekForecast struct {
+	City      utils.City
+	State     utils.State
+	Location  models.Location
+	Forecasts []cache.CachedPeriod
+	Stale     bool
+}
+
+// GridForecast is the raw grid data of the forecast for a City and State
+// Location is the stored location the City and State resolved to
+type GridForecast struct {
+	City     utils.City
+	State    utils.State
+	Location models.Location
+	Grid     apis.GridData
+}
+
+// Detailed returns the detailed forecast for the given city, state, and period
+// in the given System of units
+// an empty period defaults to today
+func (fs *ForecastService) Detailed(ctx context.Context, city, state, period string, system units.System) (DetailedForecast, error) {
+	if period == "" {
+		period = defaultPeriod
+	}
+	cleanCity, cleanState, cleanPeriod, err := utils.SanitizeDetailedInputs(city, state, period)
+	if err != nil {
+		return DetailedForecast{}, err
+	}
+	l, err := fs.resolve(ctx, cleanCity, cleanState)
+	if err != nil {
+		return DetailedForecast{}, err
+	}
+	return fs.detailed(ctx, cleanCity, cleanState, cleanPeriod, l, system)
+}
+
+// RandomDetailed returns the detailed forecast for a random stored location
+// over a random period within the next week, in the given System of units
+func (fs *ForecastService) RandomDetailed(ctx context.Context, system units.System) (DetailedForecast, error) {
+	l, err := fs.Locations.Random()
+	if err != nil {
+		return DetailedForecast{}, err
+	}
+	city := utils.SanitizeCity(l.City)
+	state, err := utils.SanitizeState(l.State)
+	if err != nil {
+		return DetailedForecast{}, errs.Wrap(errs.ErrInternal, err)
+	}
+	fs.increment(l)
+	return fs.detailed(ctx, city, state, utils.RandomPeriod(), l, system)
+}
+
+// Hourly returns the next hours hourly forecasts for the given city and state
+// in the given System of units
+// an empty hours defaults to 12
+func (fs *ForecastService) Hourly(ctx context.Context, city, state, hours string, system units.System) (HourlyForecast, error) {
+	if hours == "" {
+		hours = defaultHours
+	}
+	cleanCity, cleanState, cleanHours, err := utils.SanitizeHourlyInputs(city, state, hours)
+	if err != nil {
+		return HourlyForecast{}, err
+	}
+	l, err := fs.resolve(ctx, cleanCity, cleanState)
+	if err != nil {
+		return HourlyForecast{}, err
+	}
+	return fs.hourly(ctx, cleanCity, cleanState, cleanHours, l, system)
+}
+
+// hourly runs the hourly forecast pipeline for a resolved Location
+func (fs *ForecastService) hourly(
+	ctx context.Context,
+	city utils.City,
+	state utils.State,
+	hours int64,
+	l models.Location,
+	system units.System,
+) (HourlyForecast, error) {
+	cell, l, err := fs.gridCell(ctx, city, state, l)
+	if err != nil {
+		return HourlyForecast{}, err
+	}
+	result := HourlyForecast{City: city, State: state, Hours: hours, Location: l}
+	forecasts, err := cache.LookupHourlyForecast(fs.Cache, cell, system, hours)
+	if err == nil {
+		result.Forecasts = forecasts
+		return result, nil
+	} else if err != cache.ErrCacheMiss {
+		return HourlyForecast{}, err
+	}
+	fc, stale, err := fs.fetch(ctx, cell, l, system, productHourly)
+	if err != nil {
+		return HourlyForecast{}, err
+	}
+	if stale {
+		fc = dropEnded(fc, time.Now())
+	}
+	result.Forecasts = cache.FirstHourlyForecasts(fc, hours)
+	result.Stale = stale
+	return result, nil
+}
+
+// Week returns every available period of the detailed forecast for the
+// given city and state, in order, in the given System of units
+func (fs *ForecastService) Week(ctx context.Context, city, state string, system units.System) (WeekForecast, error) {
+	cleanCity := utils.SanitizeCity(city)
+	cleanState, err := utils.SanitizeState(state)
+	if err != nil {
+		return WeekForecast{}, err
+	}
+	l, err := fs.resolve(ctx, cleanCity, cleanState)
+	if err != nil {
+		return WeekForecast{}, err
+	}
+	return fs.week(ctx, cleanCity, cleanState, l, system)
+}
+
+// week runs the week forecast pipeline for a resolved Location
+func (fs *ForecastService) week(
+	ctx context.Context,
+	city utils.City,
+	state utils.State,
+	l models.Location,
+	system units.System,
+) (WeekForecast, error) {
+	cell, l, err := fs.gridCell(ctx, city, state, l)
+	if err != nil {
+		return WeekForecast{}, err
+	}
+	result := WeekForecast{City: city, State: state, Location: l}
+	forecasts, err := cache.LookupWeekForecast(fs.Cache, cell, system, time.Now())
+	if err == nil {
+		result.Forecasts = forecasts
+		return result, nil
+	} else if err != cache.ErrCacheMiss {
+		return WeekForecast{}, err
+	}
+	fc, stale, err := fs.fetch(ctx, cell, l, system, productDetailed)
+	if err != nil {
+		return WeekForecast{}, err
+	}
+	if stale {
+		fc = dropEnded(fc, time.Now())
+	}
+	result.Forecasts = cache.AllPeriods(fc)
+	result.Stale = stale
+	return result, nil
+}
+
+// Grid returns the raw grid data of the forecast for the given city and state
+func (fs *ForecastService) Grid(ctx context.Context, city, state string) (GridForecast, error) {
+	cleanCity := utils.SanitizeCity(city)
+	cleanState, err := utils.SanitizeState(state)
+	if err != nil {
+		return GridForecast{}, err
+	}
+	l, err := fs.resolve(ctx, cleanCity, cleanState)
+	if err != nil {
+		return GridForecast{}, err
+	}
+	return fs.grid(ctx, cleanCity, cleanState, l)
+}
+
+// grid runs the grid data pipeline for a resolved Location
+func (fs *ForecastService) grid(
+	ctx context.Context,
+	city utils.City,
+	state utils.State,
+	l models.Location,
+) (GridForecast, error) {
+	cell, l, err := fs.gridCell(ctx, city, state, l)
+	if err != nil {
+		return GridForecast{}, err
+	}
+	result := GridForecast{City: city, State: state, Location: l}
+	g, err := cache.LookupGridData(fs.Cache, cell)
+	if err == nil {
+		result.Grid = g
+		return result, nil
+	} else if err != cache.ErrCacheMiss {
+		return GridForecast{}, err
+	}
+	key := fmt.Sprintf("%s_%s", cell.Key(), productGrid)
+	val, err := fs.flight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		var g apis.GridData
+		err := fs.withPoints(ctx, l, func(points apis.Points) (err error) {
+			g, err = fs.Forecasts.FetchGridData(ctx, points)
+			return err
+		})
+		if err != nil {
+			return apis.GridData{}, err
+		}
+		cache.CacheGridData(fs.Cache, cell, g)
+		return g, nil
+	})
+	if err != nil {
+		return GridForecast{}, err
+	}
+	result.Grid = val.(apis.GridData)
+	return result, nil
+}
+
+// detailed runs the detailed forecast pipeline for a resolved Location
+func (fs *ForecastService) detailed(
+	ctx context.Context,
+	city utils.City,
+	state utils.State,
+	period utils.Period,
+	l models.Location,
+	system units.System,
+) (DetailedForecast, error) {
+	cell, l, err := fs.gridCell(ctx, city, state, l)
+	if err != nil {
+		return DetailedForecast{}, err
+	}
+	result := DetailedForecast{City: city, State: state, Period: period, Location: l}
+	forecast, err := cache.LookupDetailedForecast(fs.Cache, cell, system, period)
+	if err == nil {
+		result.Forecast = forecast
+		return withAstronomy(result), nil
+	} else if err != cache.ErrCacheMiss {
+		return DetailedForecast{}, err
+	}
+	fc, stale, err := fs.fetch(ctx, cell, l, system, productDetailed)
+	if err != nil {
+		return DetailedForecast{}, err
+	}
+	if stale {
+		fc = dropEnded(fc, time.Now())
+	}
+	forecast, found := cache.MatchDetailedForecast(fc, period)
+	if !found {
+		return DetailedForecast{}, errs.WithDetail(
+			errs.ErrPeriodUnavailable,
+			fmt.Sprintf("No forecast is available for %s.", period.Name()))
+	}
+	result.Forecast = forecast
+	result.Stale = stale
+	return withAstronomy(result), nil
+}
+
+// fetched is the shared result of a fetch
+type fetched struct {
+	forecasts apis.Forecasts
+	stale     bool
+}
+
+// fetch retrieves the detailed or hourly forecasts for a location in a
+// System of units from the ForecastProvider and caches them for its grid cell
+// Concurrent calls for the same grid cell, System and product wait on and
+// share the result of the first
+// The shared fetch is not tied to any one caller's ctx, so a caller giving
+// up does not fail the others; it is cancelled once they all have
+// If the provider is unavailable, or the caller gives up waiting, the last
+// fetched forecasts are returned instead, with stale set
+func (fs *ForecastService) fetch(
+	ctx context.Context,
+	cell cache.GridCell,
+	l models.Location,
+	system units.System,
+	product string,
+) (forecasts apis.Forecasts, stale bool, err error) {
+	key := fmt.Sprintf("%s_%s_%s", cell.Key(), system, product)
+	val, err := fs.flight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		fc, err := fs.fetchFresh(ctx, cell, l, system, product)
+		if err == nil {
+			return fetched{forecasts: fc}, nil
+		}
+		if stale, ok := fs.fetchStale(cell, system, product, err); ok {
+			return fetched{forecasts: stale, stale: true}, nil
+		}
+		return fetched{}, err
+	})
+	if err == nil {
+		f := val.(fetched)
+		return f.forecasts, f.stale, nil
+	}
+	// the caller gave up waiting
+	if ctx.Err() != nil {
+		if stale, ok := fs.fetchStale(cell, system, product, err); ok {
+			return stale, true, nil
+		}
+	}
+	return apis.Forecasts{}, false, err
+}
+
+// fetchFresh retrieves the detailed or hourly forecasts for a location from
+// the ForecastProvider and caches them for its grid cell, keeping a copy
+// to fall back on
+func (fs *ForecastService) fetchFresh(
+	ctx context.Context,
+	cell cache.GridCell,
+	l models.Location,
+	system units.System,
+	product string,
+) (apis.Forecasts, error) {
+	var fc apis.Forecasts
+	err := fs.withPoints(ctx, l, func(points apis.Points) (err error) {
+		if product == productHourly {
+			fc, err = fs.Forecasts.FetchHourlyForecasts(ctx, points, system)
+		} else {
+			fc, err = fs.Forecasts.FetchDetailedForecasts(ctx, points, system)
+		}
+		return err
+	})
+	if err != nil {
+		return apis.Forecasts{}, err
+	}
+	if product == productHourly {
+		cache.CacheHourlyForecasts(fs.Cache, cell, system, fc)
+	} else {
+		cache.CacheDetailedForecasts(fs.Cache, cell, system, fc)
+	}
+	cache.CacheStaleForecasts(fs.Cache, cell, system, product, fc)
+	return fc, nil
+}
+
+// fetchStale returns the last fetched forecasts for a grid cell and System
+// if err reports the ForecastProvider is unavailable and there are any
+func (fs *ForecastService) fetchStale(
+	cell cache.GridCell,
+	system units.System,
+	product string,
+	err error,
+) (apis.Forecasts, bool) {
+	if !errors.Is(err, errs.ErrUpstreamUnavailable) {
+		return apis.Forecasts{}, false
+	}
+	fc, lookupErr := cache.LookupStaleForecasts(fs.Cache, cell, system, product)
+	if lookupErr != nil {
+		return apis.Forecasts{}, false
+	}
+	log.Printf("Serving stale %s forecasts for grid cell %s\nError is: %s\n", product, cell.Key(), err.Error())
+	return fc, true
+}
+
+// dropEnded removes the periods of forecasts which ended before now
+func dropEnded(forecasts apis.Forecasts, now time.Time) apis.Forecasts {
+	var periods []apis.ForecastPeriod
+	for _, p := range forecasts.Properties.Periods {
+		end, err := time.Parse(time.RFC3339, p.EndTime)
+		if err == nil && end.Before(now) {
+			continue
+		}
+		periods = append(periods, p)
+	}
+	forecasts.Properties.Periods = periods
+	return forecasts
+}
+
+// gridCell returns the weather.gov grid cell of a Location, from its
+// stored GridPoint if younger than gridPointRefresh, or else its fetched
+// Points, or, if it has no stored GridPoint, the cached grid cell of its
+// City and State
+// The Location is returned with its GridPoint set if its Points were
+// fetched; concurrent calls for the same City and State share the fetch
+func (fs *ForecastService) gridCell(
+	ctx context.Context,
+	city utils.City,
+	state utils.State,
+	l models.Location,
+) (cache.GridCell, models.Location, error) {
+	if hasFreshGridPoint(l) {
+		return cache.NewGridCell(l.GridPoint), l, nil
+	}
+	// a stale stored grid point is fetched again, as the cached grid cell
+	// may be no newer
+	if !l.HasGridPoint() {
+		cell, err := cache.LookupGridCell(fs.Cache, city, state)
+		if err == nil {
+			return cell, l, nil
+		} else if err != cache.ErrCacheMiss {
+			return cache.GridCell{}, models.Location{}, err
+		}
+	}
+	key := fmt.Sprintf("%s_%s_points", city.Key(), state.Key())
+	val, err := fs.flight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		p, _, err := fs.points(ctx, l, false)
+		if err != nil {
+			return models.GridPoint{}, err
+		}
+		return p.GridPoint(time.Now().UTC()), nil
+	})
+	if err != nil {
+		return cache.GridCell{}, models.Location{}, err
+	}
+	l.GridPoint = val.(models.GridPoint)
+	return cache.NewGridCell(l.GridPoint), l, nil
+}
+
+// points returns the Points of a Location, recreated from its stored
+// GridPoint unless it is unknown, older than gridPointRefresh, or refresh
+// is set, in which case they are fetched and stored, overwriting the
+// cached Points and grid cell of the Location
+// stored reports whether they were recreated from the stored GridPoint
+func (fs *ForecastService) points(
+	ctx context.Context,
+	l models.Location,
+	refresh bool,
+) (p apis.Points, stored bool, err error) {
+	if !refresh && hasFreshGridPoint(l) {
+		return apis.PointsFromGridPoint(l.GridPoint), true, nil
+	}
+	p, err = fs.Forecasts.FetchPoints(ctx, l)
+	if err != nil {
+		return apis.Points{}, false, err
+	}
+	// locations requested by coordinates alone are not stored
+	if l.City == "" {
+		cache.CachePointsAt(fs.Cache, models.Coordinates{Lat: l.Lat, Lng: l.Lng}, p)
+		return p, false, nil
+	}
+	l.GridPoint = p.GridPoint(time.Now().UTC())
+	if err := fs.Locations.SetGridPoint(l); err != nil {
+		log.Printf("Error storing the grid point of %s, %s: %s\n", l.City, l.State, err.Error())
+	}
+	city := utils.SanitizeCity(l.City)
+	if state, err := utils.SanitizeState(l.State); err == nil {
+		cache.CacheGridCell(fs.Cache, city, state, cache.NewGridCell(l.GridPoint))
+		cache.CachePoints(fs.Cache, city, state, p)
+	}
+	return p, false, nil
+}
+
+// hasFreshGridPoint reports whether the stored GridPoint of a Location is
+// known and younger than gridPointRefresh
+func hasFreshGridPoint(l models.Location) bool {
+	return l.HasGridPoint() && time.Since(l.GridPoint.UpdatedAt) < gridPointRefresh
+}
+
+// withPoints calls fetch with the Points of a Location
+// If the Points were recreated from its stored GridPoint and fetch fails
+// because a url of theirs is not found, they are fetched again, as
+// weather.gov has moved the grid point, and fetch is retried
+func (fs *ForecastService) withPoints(
+	ctx context.Context,
+	l models.Location,
+	fetch func(apis.Points) error,
+) error {
+	p, stored, err := fs.points(ctx, l, false)
+	if err != nil {
+		return err
+	}
+	err = fetch(p)
+	if err == nil || !stored || !apis.IsNotFound(err) {
+		return err
+	}
+	log.Printf("Refreshing the grid point of %s, %s\nError is: %s\n", l.City, l.State, err.Error())
+	p, _, err = fs.points(ctx, l, true)
+	if err != nil {
+		return err
+	}
+	return fetch(p)
+}
+
+// resolve finds the stored location of a city, state pair, geocoding and
+// registering it in the LocationStore if it is not already stored
+func (fs *ForecastService) resolve(ctx context.Context, city utils.City, state utils.State) (models.Location, error) {
+	stored, err := fs.Locations.Lookup(city.Name(), state.Name())
+	if err == nil {
+		fs.increment(stored)
+		return stored, nil
+	} else if !errors.Is(err, db.ErrLocationNotFound) {
+		return models.Location{}, err
+	}
+	geocode, err := fs.Geocoder.Geocode(ctx, city.Name(), state.Name())
+	if errors.Is(err, errs.ErrLocationNotFound) {
+		return models.Location{}, errs.WithDetail(
+			errs.Wrap(errs.ErrLocationNotFound, err),
+			fmt.Sprintf("Could not find %s, %s.", city.Name(), state.Name()))
+	} else if err != nil {
+		return models.Location{}, err
+	}
+	l := models.Location{City: city.Name(), State: state.Name()}
+	l.SetLocationCoordinates(geocode.Coordinates)
+	if err := fs.Locations.Register(l); err != nil {
+		return models.Location{}, err
+	}
+	return l, nil
+}
+
+// increment counts a request for a stored location
+// failures are logged but do not fail the request
+func (fs *ForecastService) increment(l models.Location) {
+	if err := fs.Locations.Increment(l); err != nil {
+		log.Printf("Error counting a request for %s, %s: %s\n", l.City, l.State, err.Error())
+	}
+}
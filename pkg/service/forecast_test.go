@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/db"
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+const testGazetteer = `city,state,lat,lng
+Chicago,IL,41.8781,-87.6298
+Cicero,IL,41.8456,-87.7539
+`
+
+// newTestService builds a ForecastService with a forecast for today in Chicago
+func newTestService(t *testing.T) (*ForecastService, *apis.FakeForecastProvider) {
+	store, err := db.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("Unexpected error opening sqlite: %s", err.Error())
+	}
+	geocoder, err := apis.ReadGazetteer(strings.NewReader(testGazetteer))
+	if err != nil {
+		t.Fatalf("Unexpected error reading gazetteer: %s", err.Error())
+	}
+	provider := apis.NewFakeForecastProvider()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var detailed, hourly apis.Forecasts
+	detailed.Properties.Periods = []apis.ForecastPeriod{{
+		StartTime:        today.Format(time.RFC3339),
+		EndTime:          today.Add(24 * time.Hour).Format(time.RFC3339),
+		IsDaytime:        true,
+		DetailedForecast: "Sunny, with a high near 75."}}
+	for i := 0; i < 3; i++ {
+		hourly.Properties.Periods = append(hourly.Properties.Periods, apis.ForecastPeriod{
+			StartTime:       today.Add(time.Duration(i) * time.Hour).Format(time.RFC3339),
+			Temperature:     70,
+			TemperatureUnit: "F",
+			ShortForecast:   "Sunny"})
+	}
+	provider.AddLocation(models.Coordinates{Lat: 41.8781, Lng: -87.6298}, detailed, hourly)
+
+	return NewForecastService(cache.NewMemoryCache(0), store, geocoder, provider), provider
+}
+
+func TestDetailedGeocodesAndCaches(t *testing.T) {
+	fs, provider := newTestService(t)
+
+	result, err := fs.Detailed(context.Background(), "Chicago", "IL", "", units.US)
+	if err != nil || result.Forecast.DetailedForecast != "Sunny, with a high near 75." {
+		t.Fatalf("Forecast was incorrect, got: %q (%v)", result.Forecast.DetailedForecast, err)
+	}
+
+	if _, err := fs.Locations.Lookup("Chicago", "IL"); err != nil {
+		t.Errorf("Location was not registered, got: %v", err)
+	}
+
+	// a cache hit must not need the provider
+	delete(provider.Points, models.Coordinates{Lat: 41.8781, Lng: -87.6298})
+	if result, err = fs.Detailed(context.Background(), "Chicago", "IL", "today", units.US); err != nil || result.Forecast.DetailedForecast == "" {
+		t.Errorf("Forecast was not cached, got: %q (%v)", result.Forecast.DetailedForecast, err)
+	}
+}
+
+func TestHourlyClampsHours(t *testing.T) {
+	fs, _ := newTestService(t)
+
+	result, err := fs.Hourly(context.Background(), "Chicago", "IL", "48", units.US)
+	if err != nil || len(result.Forecasts) != 3 {
+		t.Errorf("Forecasts were incorrect, got: %d (%v), want: 3", len(result.Forecasts), err)
+	}
+}
+
+func TestGridPointIsStoredAndRefreshed(t *testing.T) {
+	fs, provider := newTestService(t)
+	chicago := models.Coordinates{Lat: 41.8781, Lng: -87.6298}
+	points := provider.Points[chicago]
+
+	if _, err := fs.Detailed(context.Background(), "Chicago", "IL", "", units.US); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	l, _ := fs.Locations.Lookup("Chicago", "IL")
+	if !l.HasGridPoint() || l.GridPoint.ForecastHourlyURL != points.Properties.ForecastHourly {
+		t.Fatalf("Grid point was not stored, got: %v", l.GridPoint)
+	}
+
+	// the stored grid point spares a call to /points
+	delete(provider.Points, chicago)
+	if _, err := fs.Hourly(context.Background(), "Chicago", "IL", "", units.US); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// a url of the stored grid point which is not found refreshes it
+	provider.Points[chicago] = points
+	l.GridPoint.ForecastURL = "fake://moved"
+	_ = fs.Locations.SetGridPoint(l)
+	fs.Cache = cache.NewMemoryCache(0)
+	if _, err := fs.Detailed(context.Background(), "Chicago", "IL", "", units.US); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if l, _ = fs.Locations.Lookup("Chicago", "IL"); l.GridPoint.ForecastURL != points.Properties.Forecast {
+		t.Errorf("Grid point was not refreshed, got: %s, want: %s", l.GridPoint.ForecastURL, points.Properties.Forecast)
+	}
+}
+
+func TestGridCellFollowsMovedGridPoint(t *testing.T) {
+	fs, provider := newTestService(t)
+	chicago := models.Coordinates{Lat: 41.8781, Lng: -87.6298}
+	points := provider.Points[chicago]
+	cell := cache.NewGridCell(points.GridPoint(time.Now().UTC()))
+	// a grid cell of its own, to which weather.gov moves Chicago
+	moved := provider.AddLocation(models.Coordinates{Lat: 1, Lng: 1}, apis.Forecasts{}, apis.Forecasts{})
+	movedCell := cache.NewGridCell(moved.GridPoint(time.Now().UTC()))
+	city := utils.SanitizeCity("Chicago")
+	state, _ := utils.SanitizeState("IL")
+
+	if _, err := fs.Detailed(context.Background(), "Chicago", "IL", "", units.US); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// a stored grid point older than gridPointRefresh is fetched again
+	l, _ := fs.Locations.Lookup("Chicago", "IL")
+	l.GridPoint.UpdatedAt = time.Now().UTC().Add(-2 * gridPointRefresh)
+	provider.Points[chicago] = moved
+	got, l, err := fs.gridCell(context.Background(), city, state, l)
+	if err != nil || got.Key() != movedCell.Key() || !hasFreshGridPoint(l) {
+		t.Errorf("Grid cell was incorrect, got: %s (%v), want: %s", got.Key(), err, movedCell.Key())
+	}
+
+	// a url of the stored grid point which is not found refreshes the
+	// cached grid cell along with it
+	delete(provider.Forecasts, moved.Properties.Forecast)
+	provider.Points[chicago] = points
+	fs.Cache = cache.NewMemoryCache(0)
+	if result, err := fs.Detailed(context.Background(), "Chicago", "IL", "", units.US); err != nil || result.Forecast.DetailedForecast != "Sunny, with a high near 75." {
+		t.Fatalf("Forecast was incorrect, got: %q (%v)", result.Forecast.DetailedForecast, err)
+	}
+	if got, err := cache.LookupGridCell(fs.Cache, city, state); err != nil || got.Key() != cell.Key() {
+		t.Errorf("Grid cell was incorrect, got: %s (%v), want: %s", got.Key(), err, cell.Key())
+	}
+}
+
+func TestGridCellSharesCachedForecasts(t *testing.T) {
+	fs, provider := newTestService(t)
+	points := provider.Points[models.Coordinates{Lat: 41.8781, Lng: -87.6298}]
+	// Cicero lies in the same grid cell as Chicago
+	provider.Points[models.Coordinates{Lat: 41.8456, Lng: -87.7539}] = points
+
+	if _, err := fs.Detailed(context.Background(), "Chicago", "IL", "", units.US); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Cicero must be served Chicago's cached forecast
+	delete(provider.Forecasts, points.Properties.Forecast)
+	result, err := fs.Detailed(context.Background(), "Cicero", "IL", "", units.US)
+	if err != nil || result.Forecast.DetailedForecast != "Sunny, with a high near 75." || result.Location.City != "Cicero" {
+		t.Errorf("Forecast was incorrect, got: %q for %s (%v)", result.Forecast.DetailedForecast, result.Location.City, err)
+	}
+}
+
+func TestWeekServedFromCache(t *testing.T) {
+	fs, provider := newTestService(t)
+	points := provider.Points[models.Coordinates{Lat: 41.8781, Lng: -87.6298}]
+	start := time.Now().UTC().Truncate(time.Hour)
+	var detailed apis.Forecasts
+	for i := 0; i < 4; i++ {
+		detailed.Properties.Periods = append(detailed.Properties.Periods, apis.ForecastPeriod{
+			Number:    i + 1,
+			StartTime: start.Add(time.Duration(i) * 12 * time.Hour).Format(time.RFC3339),
+			EndTime:   start.Add(time.Duration(i+1) * 12 * time.Hour).Format(time.RFC3339),
+			IsDaytime: i%2 == 0})
+	}
+	provider.Forecasts[points.Properties.Forecast] = detailed
+
+	result, err := fs.Week(context.Background(), "Chicago", "IL", units.US)
+	if err != nil || len(result.Forecasts) != 4 {
+		t.Fatalf("Forecasts were incorrect, got: %d (%v), want: 4", len(result.Forecasts), err)
+	}
+
+	// a cache hit must not need the provider, and keep the periods in order
+	delete(provider.Forecasts, points.Properties.Forecast)
+	result, err = fs.Week(context.Background(), "Chicago", "IL", units.US)
+	if err != nil || len(result.Forecasts) != 4 {
+		t.Fatalf("Forecasts were not cached, got: %d (%v), want: 4", len(result.Forecasts), err)
+	}
+	for i, fc := range result.Forecasts {
+		if fc.Number != i+1 {
+			t.Errorf("Period %d was incorrect, got: %d, want: %d", i, fc.Number, i+1)
+		}
+	}
+}
+
+// blockingProvider counts calls to FetchPoints, holding each until released
+type blockingProvider struct {
+	*apis.FakeForecastProvider
+	calls   int32
+	release chan struct{}
+}
+
+func (bp *blockingProvider) FetchPoints(ctx context.Context, l models.Location) (apis.Points, error) {
+	atomic.AddInt32(&bp.calls, 1)
+	<-bp.release
+	return bp.FakeForecastProvider.FetchPoints(ctx, l)
+}
+
+func TestConcurrentMissesCoalesce(t *testing.T) {
+	fs, provider := newTestService(t)
+	bp := &blockingProvider{FakeForecastProvider: provider, release: make(chan struct{})}
+	fs.Forecasts = bp
+	// register Chicago up front so waiters do not race to geocode it
+	_ = fs.Locations.Register(models.Location{City: "Chicago", State: "IL", Lat: 41.8781, Lng: -87.6298})
+
+	var wg sync.WaitGroup
+	results := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := fs.Detailed(context.Background(), "Chicago", "IL", "today", units.US)
+			results <- err
+		}()
+	}
+	// give every request time to miss the cache and join the fetch
+	time.Sleep(50 * time.Millisecond)
+	close(bp.release)
+	wg.Wait()
+	close(results)
+
+	for err := range results {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if calls := atomic.LoadInt32(&bp.calls); calls != 1 {
+		t.Errorf("Upstream calls were incorrect, got: %d, want: 1", calls)
+	}
+}
+
+// unavailableProvider fails every request, as during an outage of weather.gov
+type unavailableProvider struct {
+	*apis.FakeForecastProvider
+}
+
+func (up unavailableProvider) FetchPoints(ctx context.Context, l models.Location) (apis.Points, error) {
+	return apis.Points{}, errs.Wrap(errs.ErrUpstreamUnavailable, apis.ErrCircuitOpen)
+}
+
+func (up unavailableProvider) FetchDetailedForecasts(ctx context.Context, p apis.Points, system units.System) (apis.Forecasts, error) {
+	return apis.Forecasts{}, errs.Wrap(errs.ErrUpstreamUnavailable, apis.ErrCircuitOpen)
+}
+
+func (up unavailableProvider) FetchHourlyForecasts(ctx context.Context, p apis.Points, system units.System) (apis.Forecasts, error) {
+	return apis.Forecasts{}, errs.Wrap(errs.ErrUpstreamUnavailable, apis.ErrCircuitOpen)
+}
+
+func TestStaleForecastsDuringOutage(t *testing.T) {
+	fs, provider := newTestService(t)
+	if _, err := fs.Detailed(context.Background(), "Chicago", "IL", "", units.US); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// keep only the fallback copy, as if the cached periods had expired
+	l, _ := fs.Locations.Lookup("Chicago", "IL")
+	cell := cache.NewGridCell(l.GridPoint)
+	stale, err := cache.LookupStaleForecasts(fs.Cache, cell, units.US, productDetailed)
+	if err != nil {
+		t.Fatalf("Fallback forecasts were not kept, got: %v", err)
+	}
+	fs.Cache = cache.NewMemoryCache(0)
+	cache.CacheStaleForecasts(fs.Cache, cell, units.US, productDetailed, stale)
+	fs.Forecasts = unavailableProvider{provider}
+
+	result, err := fs.Detailed(context.Background(), "Chicago", "IL", "", units.US)
+	if err != nil || !result.Stale || result.Forecast.DetailedForecast != "Sunny, with a high near 75." {
+		t.Errorf("Forecast was incorrect, got: %q stale: %t (%v)", result.Forecast.DetailedForecast, result.Stale, err)
+	}
+
+	// with nothing to fall back on the outage is reported
+	if _, err := fs.Hourly(context.Background(), "Chicago", "IL", "", units.US); !errors.Is(err, errs.ErrUpstreamUnavailable) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrUpstreamUnavailable)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	fs, _ := newTestService(t)
+
+	if _, err := fs.RandomDetailed(context.Background(), units.US); !errors.Is(err, errs.ErrLocationNotFound) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrLocationNotFound)
+	}
+	if _, err := fs.Detailed(context.Background(), "Chicago", "West Dakota", "", units.US); !errors.Is(err, errs.ErrInvalidState) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrInvalidState)
+	}
+	if _, err := fs.Hourly(context.Background(), "Chicago", "IL", "0", units.US); !errors.Is(err, errs.ErrInvalidHours) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrInvalidHours)
+	}
+	if _, err := fs.Detailed(context.Background(), "Chicago", "IL", "tomorrow", units.US); !errors.Is(err, errs.ErrPeriodUnavailable) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrPeriodUnavailable)
+	}
+	if _, err := fs.Detailed(context.Background(), "Gotham", "NY", "", units.US); !errors.Is(err, errs.ErrLocationNotFound) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrLocationNotFound)
+	}
+
+}
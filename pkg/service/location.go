@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+// LocationMetadata is the weather.gov metadata of a City and State
+// Location is the stored location the City and State resolved to, and
+// Points the metadata of its grid point
+type LocationMetadata struct {
+	City     utils.City
+	State    utils.State
+	Location models.Location
+	Points   apis.Points
+}
+
+// Location returns the stored location of the given city and state along
+// with the weather.gov metadata of its grid point (forecast office, zones,
+// radar station, the nearest city, ...)
+func (fs *ForecastService) Location(ctx context.Context, city, state string) (LocationMetadata, error) {
+	cleanCity := utils.SanitizeCity(city)
+	cleanState, err := utils.SanitizeState(state)
+	if err != nil {
+		return LocationMetadata{}, err
+	}
+	l, err := fs.resolve(ctx, cleanCity, cleanState)
+	if err != nil {
+		return LocationMetadata{}, err
+	}
+	p, err := cache.LookupPoints(fs.Cache, cleanCity, cleanState)
+	if err == cache.ErrCacheMiss {
+		p, err = fs.metadata(ctx, cleanCity, cleanState, l)
+	}
+	if err != nil {
+		return LocationMetadata{}, err
+	}
+	l.GridPoint = p.GridPoint(time.Now().UTC())
+	return LocationMetadata{City: cleanCity, State: cleanState, Location: l, Points: p}, nil
+}
+
+// metadata fetches the Points of a stored location, as its stored grid
+// point lacks some of their metadata; concurrent calls for the same City
+// and State share the fetch
+func (fs *ForecastService) metadata(ctx context.Context, city utils.City, state utils.State, l models.Location) (apis.Points, error) {
+	key := fmt.Sprintf("%s_%s_metadata", city.Key(), state.Key())
+	val, err := fs.flight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		p, _, err := fs.points(ctx, l, true)
+		return p, err
+	})
+	if err != nil {
+		return apis.Points{}, err
+	}
+	return val.(apis.Points), nil
+}
+
+// LocationAt returns the Location of the given latitude and longitude,
+// with its GridPoint set from their weather.gov Points, which are cached by
+// coordinates, along with the City and State weather.gov reports nearest
+// to them
+// It neither geocodes nor stores the Location
+// The City and State are empty if weather.gov reports no nearest city, or
+// one outside of the US states (e.g. in DC or Puerto Rico), in which case
+// the Location is known by its Label alone
+func (fs *ForecastService) LocationAt(ctx context.Context, lat, lng string) (LocationMetadata, error) {
+	coordinates, err := utils.SanitizeCoordinates(lat, lng)
+	if err != nil {
+		return LocationMetadata{}, err
+	}
+	var l models.Location
+	l.SetLocationCoordinates(coordinates)
+	p, err := cache.LookupPointsAt(fs.Cache, coordinates)
+	if err == cache.ErrCacheMiss {
+		p, err = fs.pointsAt(ctx, l)
+	}
+	if err != nil {
+		return LocationMetadata{}, err
+	}
+	l.GridPoint = p.GridPoint(time.Now().UTC())
+	result := LocationMetadata{Location: l, Points: p}
+	relative := p.Properties.RelativeLocation.Properties
+	if relative.City == "" {
+		return result, nil
+	}
+	result.Location.Label = fmt.Sprintf("near %s, %s", relative.City, relative.State)
+	state, err := utils.SanitizeState(relative.State)
+	if err != nil {
+		log.Printf("The nearest city to %.4f, %.4f is not in a US state: %s\n", l.Lat, l.Lng, err.Error())
+		return result, nil
+	}
+	result.City, result.State = utils.SanitizeCity(relative.City), state
+	return result, nil
+}
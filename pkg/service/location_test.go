@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kylep342/thorcast-server/pkg/models"
+)
+
+func TestLocationMetadataIsCached(t *testing.T) {
+	fs, provider := newTestService(t)
+
+	result, err := fs.Location(context.Background(), "Chicago", "IL")
+	if err != nil || result.Points.Properties.Cwa != "TST" || !result.Location.HasGridPoint() {
+		t.Fatalf("Metadata was incorrect, got: %+v (%v)", result.Points.Properties, err)
+	}
+
+	// a cache hit must not need the provider, and sets the grid point from
+	// the cached metadata as a miss does, whether or not one is stored
+	delete(provider.Points, models.Coordinates{Lat: 41.8781, Lng: -87.6298})
+	l := result.Location
+	l.GridPoint = models.GridPoint{}
+	_ = fs.Locations.SetGridPoint(l)
+	if result, err = fs.Location(context.Background(), "Chicago", "IL"); err != nil || result.Points.Properties.Cwa != "TST" {
+		t.Errorf("Metadata was not cached, got: %+v (%v)", result.Points.Properties, err)
+	}
+	if result.Location.GridPoint.Cwa != "TST" || result.Location.GridPoint.ForecastURL != result.Points.Properties.Forecast {
+		t.Errorf("Grid point was not set from the cached metadata, got: %+v", result.Location.GridPoint)
+	}
+}
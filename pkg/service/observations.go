@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+// Number of the nearest stations tried for an observation with values
+// for every current condition
+const maxStations = 3
+
+// CurrentConditions is the latest observation near a City and State
+// Location is the stored location the City and State resolved to, or the
+// coordinates requested, labelled by the City and State nearest to them
+type CurrentConditions struct {
+	City        utils.City
+	State       utils.State
+	Location    models.Location
+	Observation cache.CachedObservation
+}
+
+// LatestObservation returns the latest observation of the nearest station
+// to the given city and state
+// If it is missing values, the next nearest stations are tried in turn
+func (fs *ForecastService) LatestObservation(ctx context.Context, city, state string) (CurrentConditions, error) {
+	cleanCity := utils.SanitizeCity(city)
+	cleanState, err := utils.SanitizeState(state)
+	if err != nil {
+		return CurrentConditions{}, err
+	}
+	l, err := fs.resolve(ctx, cleanCity, cleanState)
+	if err != nil {
+		return CurrentConditions{}, err
+	}
+	return fs.latestObservation(ctx, cleanCity, cleanState, l)
+}
+
+// LatestObservationAt returns the latest observation of the nearest
+// station to the given latitude and longitude
+func (fs *ForecastService) LatestObservationAt(ctx context.Context, lat, lng string) (CurrentConditions, error) {
+	city, state, l, err := fs.locate(ctx, lat, lng)
+	if err != nil {
+		return CurrentConditions{}, err
+	}
+	return fs.latestObservation(ctx, city, state, l)
+}
+
+// latestObservation looks up the latest observation near a Location in
+// the cache, observing and caching it on a miss
+// Observations of stored locations are cached by City and State, and of
+// locations requested by coordinates alone by their coordinates
+func (fs *ForecastService) latestObservation(
+	ctx context.Context,
+	city utils.City,
+	state utils.State,
+	l models.Location,
+) (CurrentConditions, error) {
+	if fs.Observations == nil {
+		return CurrentConditions{}, errs.Wrap(errs.ErrInternal, errors.New("no observation provider is configured"))
+	}
+	coordinates := models.Coordinates{Lat: l.Lat, Lng: l.Lng}
+	result := CurrentConditions{City: city, State: state, Location: l}
+	var o cache.CachedObservation
+	var err error
+	if l.City != "" {
+		o, err = cache.LookupObservation(fs.Cache, city, state)
+	} else {
+		o, err = cache.LookupObservationAt(fs.Cache, coordinates)
+	}
+	if err == nil {
+		result.Observation = o
+		return result, nil
+	} else if err != cache.ErrCacheMiss {
+		return CurrentConditions{}, err
+	}
+	key := fmt.Sprintf("%s_%s_%s", city.Key(), state.Key(), productObserved)
+	if l.City == "" {
+		key = fmt.Sprintf("%s_%.4f_%.4f", productObserved, l.Lat, l.Lng)
+	}
+	val, err := fs.flight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		o, err := fs.observe(ctx, l)
+		if err != nil {
+			return cache.CachedObservation{}, err
+		}
+		if l.City != "" {
+			cache.CacheObservation(fs.Cache, city, state, o)
+		} else {
+			cache.CacheObservationAt(fs.Cache, coordinates, o)
+		}
+		return o, nil
+	})
+	if err != nil {
+		return CurrentConditions{}, err
+	}
+	result.Observation = val.(cache.CachedObservation)
+	return result, nil
+}
+
+// observe fetches the latest observation of the nearest station to a
+// Location which has values for every current condition, settling for
+// the nearest station's if none of the maxStations nearest do
+func (fs *ForecastService) observe(ctx context.Context, l models.Location) (cache.CachedObservation, error) {
+	var stations apis.Stations
+	err := fs.withPoints(ctx, l, func(points apis.Points) (err error) {
+		stations, err = fs.Observations.FetchStations(ctx, points)
+		return err
+	})
+	if err != nil {
+		return cache.CachedObservation{}, err
+	}
+	nearest := nearestStations(stations, models.Coordinates{Lat: l.Lat, Lng: l.Lng})
+	if len(nearest) == 0 {
+		return cache.CachedObservation{}, errs.WithDetail(
+			errs.ErrOutOfCoverage,
+			fmt.Sprintf("No observation stations are near %s.", l.Name()))
+	}
+	if len(nearest) > maxStations {
+		nearest = nearest[:maxStations]
+	}
+	var fallback *cache.CachedObservation
+	var lastErr error
+	for _, s := range nearest {
+		o, err := fs.Observations.FetchLatestObservation(ctx, s.Station.Properties.StationIdentifier)
+		if err != nil {
+			log.Printf("Error fetching latest observation of %s\nError is: %s\n", s.Station.Properties.StationIdentifier, err.Error())
+			lastErr = err
+			continue
+		}
+		s.Observation = o
+		if o.IsComplete() {
+			return s, nil
+		}
+		if fallback == nil {
+			fallback = &cache.CachedObservation{Station: s.Station, Distance: s.Distance, Observation: o}
+		}
+	}
+	if fallback != nil {
+		return *fallback, nil
+	}
+	return cache.CachedObservation{}, lastErr
+}
+
+// nearestStations returns stations ordered by their distance from c
+func nearestStations(stations apis.Stations, c models.Coordinates) []cache.CachedObservation {
+	nearest := make([]cache.CachedObservation, 0, len(stations.Features))
+	for _, s := range stations.Features {
+		if len(s.Geometry.Coordinates) < 2 {
+			continue
+		}
+		// GeoJSON coordinates are ordered lng, lat
+		at := models.Coordinates{Lat: s.Geometry.Coordinates[1], Lng: s.Geometry.Coordinates[0]}
+		nearest = append(nearest, cache.CachedObservation{Station: s, Distance: c.DistanceTo(at)})
+	}
+	sort.SliceStable(nearest, func(i, j int) bool { return nearest[i].Distance < nearest[j].Distance })
+	return nearest
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kylep342/thorcast-server/pkg/apis"
+	"github.com/kylep342/thorcast-server/pkg/cache"
+	"github.com/kylep342/thorcast-server/pkg/models"
+)
+
+// newTestStation creates a Station at the given coordinates
+func newTestStation(id string, lat, lng float64) apis.Station {
+	var s apis.Station
+	s.Properties.StationIdentifier = id
+	s.Geometry.Coordinates = []float64{lng, lat}
+	return s
+}
+
+// newTestObservation creates an Observation, complete unless temperature is nil
+func newTestObservation(description string, temperature *float64) apis.Observation {
+	value := 1.0
+	var o apis.Observation
+	o.Properties.TextDescription = description
+	o.Properties.Temperature = apis.Measurement{UnitCode: "wmoUnit:degC", Value: temperature}
+	o.Properties.WindSpeed = apis.Measurement{UnitCode: "wmoUnit:km_h-1", Value: &value}
+	o.Properties.RelativeHumidity = apis.Measurement{UnitCode: "wmoUnit:percent", Value: &value}
+	o.Properties.BarometricPressure = apis.Measurement{UnitCode: "wmoUnit:Pa", Value: &value}
+	o.Properties.Visibility = apis.Measurement{UnitCode: "wmoUnit:m", Value: &value}
+	return o
+}
+
+func TestLatestObservationFallsBack(t *testing.T) {
+	fs, provider := newTestService(t)
+	points := provider.Points[models.Coordinates{Lat: 41.8781, Lng: -87.6298}]
+	// listed farthest first, to check stations are ordered by distance
+	provider.Stations[points.Properties.ObservationStations] = apis.Stations{Features: []apis.Station{
+		newTestStation("KORD", 41.9602, -87.9316),
+		newTestStation("KMDW", 41.7841, -87.7551),
+		newTestStation("KCGX", 41.8586, -87.6081),
+	}}
+	temperature := 22.0
+	provider.Observations["KCGX"] = newTestObservation("Clear", nil)
+	provider.Observations["KMDW"] = newTestObservation("Mostly Clear", &temperature)
+	provider.Observations["KORD"] = newTestObservation("Cloudy", &temperature)
+
+	result, err := fs.LatestObservation(context.Background(), "Chicago", "IL")
+
+	if err != nil || result.Observation.Station.Properties.StationIdentifier != "KMDW" {
+		t.Fatalf("Station was incorrect, got: %s (%v), want: KMDW", result.Observation.Station.Properties.StationIdentifier, err)
+	}
+	if result.Observation.Distance < 14 || result.Observation.Distance > 15 {
+		t.Errorf("Distance was incorrect, got: %v, want: about 14.7km", result.Observation.Distance)
+	}
+
+	// settle for the nearest station when none are complete
+	delete(provider.Observations, "KMDW")
+	delete(provider.Observations, "KORD")
+	fs.Cache = cache.NewMemoryCache(0)
+	result, err = fs.LatestObservation(context.Background(), "Chicago", "IL")
+	if err != nil || result.Observation.Station.Properties.StationIdentifier != "KCGX" {
+		t.Errorf("Station was incorrect, got: %s (%v), want: KCGX", result.Observation.Station.Properties.StationIdentifier, err)
+	}
+}
+
+func TestLatestObservationAcceptsSeaLevelPressure(t *testing.T) {
+	fs, provider := newTestService(t)
+	points := provider.Points[models.Coordinates{Lat: 41.8781, Lng: -87.6298}]
+	provider.Stations[points.Properties.ObservationStations] = apis.Stations{Features: []apis.Station{
+		newTestStation("KCGX", 41.8586, -87.6081),
+		newTestStation("KMDW", 41.7841, -87.7551),
+	}}
+	temperature := 22.0
+	seaLevel := newTestObservation("Clear", &temperature)
+	seaLevel.Properties.SeaLevelPressure = seaLevel.Properties.BarometricPressure
+	seaLevel.Properties.BarometricPressure = apis.Measurement{UnitCode: "wmoUnit:Pa"}
+	provider.Observations["KCGX"] = seaLevel
+	provider.Observations["KMDW"] = newTestObservation("Mostly Clear", &temperature)
+
+	result, err := fs.LatestObservation(context.Background(), "Chicago", "IL")
+	if err != nil || result.Observation.Station.Properties.StationIdentifier != "KCGX" {
+		t.Errorf("Station was incorrect, got: %s (%v), want: KCGX", result.Observation.Station.Properties.StationIdentifier, err)
+	}
+}
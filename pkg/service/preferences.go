@@ -0,0 +1,71 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/units"
+	"github.com/kylep342/thorcast-server/pkg/utils"
+)
+
+// Default System of units of forecasts, the units of weather.gov's
+// text forecasts
+const defaultUnits = units.US
+
+// Units returns the System of units to respond to a request in
+// requested is the units parameter of the request, which takes precedence
+// over the System the user (if any) prefers, which takes precedence over
+// the default of US units
+func (fs *ForecastService) Units(user, requested string) (units.System, error) {
+	if requested != "" {
+		return utils.SanitizeUnits(requested)
+	}
+	user = strings.TrimSpace(user)
+	if user == "" || fs.Preferences == nil {
+		return defaultUnits, nil
+	}
+	system, err := fs.Preferences.Units(user)
+	if err != nil {
+		return "", err
+	}
+	if system == "" {
+		return defaultUnits, nil
+	}
+	return system, nil
+}
+
+// PreferredUnits returns the System of units the given user prefers,
+// defaulting to US units
+func (fs *ForecastService) PreferredUnits(user string) (units.System, error) {
+	if err := requireUser(user); err != nil {
+		return "", err
+	}
+	return fs.Units(user, "")
+}
+
+// SetUnits stores the System of units the given user prefers
+func (fs *ForecastService) SetUnits(user, requested string) (units.System, error) {
+	if err := requireUser(user); err != nil {
+		return "", err
+	}
+	user = strings.TrimSpace(user)
+	system, err := utils.SanitizeUnits(requested)
+	if err != nil {
+		return "", err
+	}
+	if fs.Preferences == nil {
+		return "", errs.WithDetail(errs.ErrStorageUnavailable, "Preferences cannot be stored.")
+	}
+	if err := fs.Preferences.SetUnits(user, system); err != nil {
+		return "", err
+	}
+	return system, nil
+}
+
+// requireUser returns errs.ErrInvalidUser if no user is given
+func requireUser(user string) error {
+	if strings.TrimSpace(user) == "" {
+		return errs.WithDetail(errs.ErrInvalidUser, "Preferences are kept per user, identified by the X-Thorcast-User header.")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/units"
+)
+
+func TestUnitsPreference(t *testing.T) {
+	fs, _ := newTestService(t)
+
+	if system, err := fs.Units("U123", ""); err != nil || system != units.US {
+		t.Errorf("Units were incorrect, got: %q (%v), want: %q", system, err, units.US)
+	}
+	if _, err := fs.SetUnits("U123", "si"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if system, err := fs.Units("U123", ""); err != nil || system != units.SI {
+		t.Errorf("Units were incorrect, got: %q (%v), want: %q", system, err, units.SI)
+	}
+	// the units parameter of a request overrides the preference
+	if system, err := fs.Units("U123", "us"); err != nil || system != units.US {
+		t.Errorf("Units were incorrect, got: %q (%v), want: %q", system, err, units.US)
+	}
+	if _, err := fs.SetUnits("", "si"); !errors.Is(err, errs.ErrInvalidUser) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrInvalidUser)
+	}
+}
+
+func TestUnitsAreCachedSeparately(t *testing.T) {
+	fs, _ := newTestService(t)
+
+	for _, tc := range []struct {
+		system units.System
+		value  float64
+		unit   string
+	}{
+		{units.US, 70, "F"},
+		{units.SI, 21, "C"},
+		{units.US, 70, "F"},
+	} {
+		result, err := fs.Hourly(context.Background(), "Chicago", "IL", "1", tc.system)
+		if err != nil || len(result.Forecasts) != 1 {
+			t.Fatalf("Forecasts were incorrect, got: %v (%v)", result.Forecasts, err)
+		}
+		fc := result.Forecasts[0]
+		if fc.Temperature != tc.value || fc.TemperatureUnit != tc.unit {
+			t.Errorf("Temperature was incorrect, got: %v %s, want: %v %s", fc.Temperature, fc.TemperatureUnit, tc.value, tc.unit)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package units
+
+import (
+	"strings"
+)
+
+// System is a system of units measurements are reported in
+type System string
+
+// Systems of units
+// US is US customary units (°F, mph, inHg, mi), the units of
+// weather.gov's text forecasts
+// SI is metric units (°C, km/h, hPa, km), the units of weather.gov's
+// observations and raw forecast data
+const (
+	US System = "us"
+	SI System = "si"
+)
+
+// conversion converts a value in a weather.gov unit to a System
+type conversion struct {
+	unit    string
+	convert func(float64) float64
+}
+
+// Conversions of weather.gov units, by unit code (without its namespace)
+// into each System
+var conversions = map[string]map[System]conversion{
+	"degC": {
+		US: {"°F", func(v float64) float64 { return v*9/5 + 32 }},
+		SI: {"°C", identity},
+	},
+	"degF": {
+		US: {"°F", identity},
+		SI: {"°C", func(v float64) float64 { return (v - 32) * 5 / 9 }},
+	},
+	"km_h-1": {
+		US: {"mph", func(v float64) float64 { return v / 1.609344 }},
+		SI: {"km/h", identity},
+	},
+	"m_s-1": {
+		US: {"mph", func(v float64) float64 { return v * 3.6 / 1.609344 }},
+		SI: {"km/h", func(v float64) float64 { return v * 3.6 }},
+	},
+	"Pa": {
+		US: {"inHg", func(v float64) float64 { return v / 3386.389 }},
+		SI: {"hPa", func(v float64) float64 { return v / 100 }},
+	},
+	"m": {
+		US: {"mi", func(v float64) float64 { return v / 1609.344 }},
+		SI: {"km", func(v float64) float64 { return v / 1000 }},
+	},
+	"mm": {
+		US: {"in", func(v float64) float64 { return v / 25.4 }},
+		SI: {"mm", identity},
+	},
+	"percent": {
+		US: {"%", identity},
+		SI: {"%", identity},
+	},
+	"degree_(angle)": {
+		US: {"°", identity},
+		SI: {"°", identity},
+	},
+}
+
+func identity(v float64) float64 { return v }
+
+// Convert converts value, measured in the weather.gov unit unitCode
+// (e.g. wmoUnit:degC), into the given System
+// It returns the converted value and its unit
+// Values in unknown units are returned as is, with the unit code
+// stripped of its namespace
+func Convert(value float64, unitCode string, system System) (float64, string) {
+	code := unitCode[strings.LastIndex(unitCode, ":")+1:]
+	c, ok := conversions[code][system]
+	if !ok {
+		return value, code
+	}
+	return c.convert(value), c.unit
+}
@@ -0,0 +1,32 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		value    float64
+		unitCode string
+		system   System
+		want     float64
+		unit     string
+	}{
+		{20, "wmoUnit:degC", US, 68, "°F"},
+		{20, "wmoUnit:degC", SI, 20, "°C"},
+		{68, "degF", SI, 20, "°C"},
+		{16.09344, "wmoUnit:km_h-1", US, 10, "mph"},
+		{101592, "wmoUnit:Pa", US, 30, "inHg"},
+		{101592, "wmoUnit:Pa", SI, 1015.92, "hPa"},
+		{16093.44, "wmoUnit:m", US, 10, "mi"},
+		{55, "wmoUnit:percent", US, 55, "%"},
+		{3, "wmoUnit:furlong", US, 3, "furlong"},
+	}
+	for _, c := range cases {
+		got, unit := Convert(c.value, c.unitCode, c.system)
+		if math.Abs(got-c.want) > 0.01 || unit != c.unit {
+			t.Errorf("Convert(%v, %s, %s) was incorrect, got: %v %s, want: %v %s", c.value, c.unitCode, c.system, got, unit, c.want, c.unit)
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package utils
 
 import (
-	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
 )
 
 // Slice containing all string representations of different times of day
@@ -107,6 +111,36 @@ type Period struct {
 	isDaytime bool
 }
 
+// URL returns the City formatted for use in a URL
+func (c City) URL() string { return c.asURL }
+
+// Key returns the City formatted for use in a cache key
+func (c City) Key() string { return c.asKey }
+
+// Name returns the City formatted for display
+func (c City) Name() string { return c.asName }
+
+// URL returns the State formatted for use in a URL
+func (s State) URL() string { return s.asURL }
+
+// Key returns the State formatted for use in a cache key
+func (s State) Key() string { return s.asKey }
+
+// Name returns the State formatted for display
+func (s State) Name() string { return s.asName }
+
+// Key returns the Period formatted for use in a cache key
+func (p Period) Key() string { return p.asKey }
+
+// Name returns the Period formatted for display
+func (p Period) Name() string { return p.asName }
+
+// DayOfWeek returns the proper case day of the week of the Period
+func (p Period) DayOfWeek() string { return p.dayOfWeek }
+
+// IsDaytime reports whether the Period is a daytime period
+func (p Period) IsDaytime() bool { return p.isDaytime }
+
 func init() {
 	rand.Seed(time.Now().UTC().UnixNano())
 }
@@ -118,7 +152,7 @@ func SanitizeDetailedInputs(city string, state string, period string) (City, Sta
 	if err != nil {
 		return City{}, State{}, Period{}, err
 	}
-	cleanPeriod, err := sanitizePeriod(period)
+	cleanPeriod, err := SanitizePeriod(period)
 	if err != nil {
 		return City{}, State{}, Period{}, err
 	}
@@ -133,13 +167,29 @@ func SanitizeHourlyInputs(city string, state string, hours string) (City, State,
 	if err != nil {
 		return City{}, State{}, 0, err
 	}
-	cleanHours, err := strconv.ParseInt(hours, 10, 64)
+	cleanHours, err := SanitizeHours(hours)
 	if err != nil {
 		return City{}, State{}, 0, err
 	}
 	return cleanCity, cleanState, cleanHours, nil
 }
 
+// SanitizeHours parses a number of hours, which must be at least 1
+func SanitizeHours(hours string) (int64, error) {
+	cleanHours, err := strconv.ParseInt(hours, 10, 64)
+	if err != nil {
+		return 0, errs.WithDetail(
+			errs.Wrap(errs.ErrInvalidHours, err),
+			fmt.Sprintf("%q is not a whole number of hours.", hours))
+	}
+	if cleanHours < 1 {
+		return 0, errs.WithDetail(
+			errs.ErrInvalidHours,
+			"hours must be at least 1.")
+	}
+	return cleanHours, nil
+}
+
 // SanitizeCity creates a City struct from a given city name string
 func SanitizeCity(city string) City {
 	return City{asURL: separatorRE.ReplaceAllString(city, "+"),
@@ -153,7 +203,55 @@ func SanitizeState(state string) (State, error) {
 	if cleanState, ok := stateCodes[key]; ok {
 		return State{asURL: cleanState, asKey: strings.ToLower(cleanState), asName: cleanState}, nil
 	}
-	return State{}, errors.New("invalid state name")
+	return State{}, errs.WithDetail(
+		errs.ErrInvalidState,
+		fmt.Sprintf("%q is not a US state name or postal code.", state))
+}
+
+// SanitizeCoordinates parses a latitude and longitude from strings
+// rounding them to the 4 decimal places weather.gov accepts
+func SanitizeCoordinates(lat string, lng string) (models.Coordinates, error) {
+	cleanLat, err := strconv.ParseFloat(lat, 64)
+	if err != nil || math.IsNaN(cleanLat) || cleanLat < -90 || cleanLat > 90 {
+		return models.Coordinates{}, errs.WithParam(
+			errs.WithDetail(errs.ErrInvalidCoordinates, fmt.Sprintf("%q is not a latitude between -90 and 90.", lat)),
+			"lat")
+	}
+	cleanLng, err := strconv.ParseFloat(lng, 64)
+	if err != nil || math.IsNaN(cleanLng) || cleanLng < -180 || cleanLng > 180 {
+		return models.Coordinates{}, errs.WithParam(
+			errs.WithDetail(errs.ErrInvalidCoordinates, fmt.Sprintf("%q is not a longitude between -180 and 180.", lng)),
+			"lng")
+	}
+	return models.Coordinates{Lat: roundCoordinate(cleanLat), Lng: roundCoordinate(cleanLng)}, nil
+}
+
+// SanitizeUnits parses a system of units from a string, case insensitively
+func SanitizeUnits(system string) (units.System, error) {
+	switch cleanSystem := units.System(strings.ToLower(strings.TrimSpace(system))); cleanSystem {
+	case units.US, units.SI:
+		return cleanSystem, nil
+	}
+	return "", errs.WithDetail(
+		errs.ErrInvalidUnits,
+		fmt.Sprintf("%q is not a system of units, use us or si.", system))
+}
+
+// SanitizeDate parses a calendar date in the form 2006-01-02, returning
+// its midnight in loc
+func SanitizeDate(date string, loc *time.Location) (time.Time, error) {
+	cleanDate, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(date), loc)
+	if err != nil {
+		return time.Time{}, errs.WithDetail(
+			errs.Wrap(errs.ErrInvalidDate, err),
+			fmt.Sprintf("%q is not a date in the form YYYY-MM-DD.", date))
+	}
+	return cleanDate, nil
+}
+
+// roundCoordinate rounds a latitude or longitude to 4 decimal places
+func roundCoordinate(c float64) float64 {
+	return math.Round(c*1e4) / 1e4
 }
 
 func sanitizeLocation(city string, state string) (City, State, error) {
@@ -162,8 +260,8 @@ func sanitizeLocation(city string, state string) (City, State, error) {
 	return cleanCity, cleanState, err
 }
 
-// sanitizePeriod creates a Period struct from a given period name string
-func sanitizePeriod(period string) (Period, error) {
+// SanitizePeriod creates a Period struct from a given period name string
+func SanitizePeriod(period string) (Period, error) {
 	var cleanPeriod string
 	switch {
 	case strings.Contains(strings.ToLower(period), "today"):
@@ -197,7 +295,9 @@ func sanitizePeriod(period string) (Period, error) {
 			dayOfWeek: strings.Title(m[1]),
 			isDaytime: false}, nil
 	} else {
-		return Period{}, errors.New("invalid period")
+		return Period{}, errs.WithDetail(
+			errs.ErrInvalidPeriod,
+			fmt.Sprintf("%q is not a day of the week, today, tonight, or tomorrow.", period))
 	}
 }
 
@@ -206,6 +306,6 @@ func sanitizePeriod(period string) (Period, error) {
 func RandomPeriod() Period {
 	dayOfWeek := time.Now().UTC().AddDate(0, 0, rand.Intn(7)).Weekday().String()
 	timeOfDay := timesOfDay[rand.Intn(2)]
-	p, _ := sanitizePeriod(fmt.Sprintf("%s%s", dayOfWeek, timeOfDay))
+	p, _ := SanitizePeriod(fmt.Sprintf("%s%s", dayOfWeek, timeOfDay))
 	return p
 }
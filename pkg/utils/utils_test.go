@@ -1,10 +1,15 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/kylep342/thorcast-server/pkg/errs"
+	"github.com/kylep342/thorcast-server/pkg/models"
+	"github.com/kylep342/thorcast-server/pkg/units"
 )
 
 func TestSanitizeState(t *testing.T) {
@@ -42,7 +47,7 @@ func TestSanitizeCity(t *testing.T) {
 }
 
 func TestSanitizePeriodRelativeDate(t *testing.T) {
-	checkPeriod, _ := sanitizePeriod("Tomorrow Night")
+	checkPeriod, _ := SanitizePeriod("Tomorrow Night")
 
 	relDate := fmt.Sprintf("%s", strings.ToLower(time.Now().UTC().AddDate(0, 0, 1).Weekday().String()))
 
@@ -58,7 +63,7 @@ func TestSanitizePeriodRelativeDate(t *testing.T) {
 }
 
 func TestSanitizePeriodAbsoluteDate(t *testing.T) {
-	checkPeriod, _ := sanitizePeriod("WEDNESDAY")
+	checkPeriod, _ := SanitizePeriod("WEDNESDAY")
 
 	target := Period{
 		asKey:     "wednesday",
@@ -72,7 +77,7 @@ func TestSanitizePeriodAbsoluteDate(t *testing.T) {
 }
 
 func TestSanitizePeriodInvalidPeriod(t *testing.T) {
-	checkPeriod, err := sanitizePeriod("Reindeer")
+	checkPeriod, err := SanitizePeriod("Reindeer")
 
 	target := Period{}
 
@@ -84,3 +89,47 @@ func TestSanitizePeriodInvalidPeriod(t *testing.T) {
 		t.Errorf("Period was invalid, got: %v, wanted: %v", checkPeriod, target)
 	}
 }
+
+func TestSanitizeCoordinates(t *testing.T) {
+	checkCoordinates, err := SanitizeCoordinates("41.878114", "-87.629798")
+
+	target := models.Coordinates{Lat: 41.8781, Lng: -87.6298}
+
+	if err != nil || checkCoordinates != target {
+		t.Errorf("Coordinates were incorrect, got: %v (%v), want: %v", checkCoordinates, err, target)
+	}
+}
+
+func TestSanitizeCoordinatesOutOfRange(t *testing.T) {
+	_, err := SanitizeCoordinates("41.8781", "-187.6298")
+
+	if !errors.Is(err, errs.ErrInvalidCoordinates) || errs.As(err).Param != "lng" {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrInvalidCoordinates)
+	}
+}
+
+func TestSanitizeUnits(t *testing.T) {
+	checkUnits, err := SanitizeUnits("SI")
+
+	if err != nil || checkUnits != units.SI {
+		t.Errorf("Units were incorrect, got: %v (%v), want: %v", checkUnits, err, units.SI)
+	}
+
+	if _, err := SanitizeUnits("kelvin"); !errors.Is(err, errs.ErrInvalidUnits) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrInvalidUnits)
+	}
+}
+
+func TestSanitizeDate(t *testing.T) {
+	loc := time.FixedZone("CDT", -5*3600)
+	checkDate, err := SanitizeDate("2020-06-20", loc)
+	want := time.Date(2020, 6, 20, 0, 0, 0, 0, loc)
+
+	if err != nil || !checkDate.Equal(want) || checkDate.Location() != loc {
+		t.Errorf("Date was incorrect, got: %v (%v), want: %v", checkDate, err, want)
+	}
+
+	if _, err := SanitizeDate("06/20/2020", loc); !errors.Is(err, errs.ErrInvalidDate) {
+		t.Errorf("Error was incorrect, got: %v, want: %v", err, errs.ErrInvalidDate)
+	}
+}